@@ -0,0 +1,255 @@
+package cliutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/dockerapi"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// engineRuntime runs seed images with docker by talking to the Docker Engine API directly.
+// Errors and exit codes come from the API rather than from the text written to stderr
+type engineRuntime struct {
+	client *dockerapi.Client
+	// auths holds the credentials of each registry logged in to, keyed by registry
+	auths map[string]dockerapi.AuthConfig
+	// info describes the docker host. It is only requested when needed
+	infoOnce sync.Once
+	info     *dockerapi.SystemInfo
+}
+
+func newEngineRuntime(client *dockerapi.Client) *engineRuntime {
+	return &engineRuntime{client: client, auths: map[string]dockerapi.AuthConfig{}}
+}
+
+func (r *engineRuntime) Name() string {
+	return constants.DockerRuntime
+}
+
+// CommandArgsInit returns the docker command line for the commands that aren't run through
+// the API (listing images) and for dry runs. The socket is reachable so sudo isn't needed
+func (r *engineRuntime) CommandArgsInit() ([]string, string) {
+	return nil, constants.DockerRuntime
+}
+
+func (r *engineRuntime) SupportsLabels() bool {
+	return true
+}
+
+func (r *engineRuntime) SupportsReferenceFilter() bool {
+	return true
+}
+
+func (r *engineRuntime) ImageExists(image string) (bool, error) {
+	_, err := r.client.ImageInspect(image)
+	if dockerapi.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *engineRuntime) ImageLabel(image, label string) (string, error) {
+	info, err := r.client.ImageInspect(image)
+	if err != nil {
+		return "", err
+	}
+	return info.Config.Labels[label], nil
+}
+
+// Login checks the credentials with the registry and keeps them for later pulls, pushes and
+// builds. Nothing is written to the docker config of the user
+func (r *engineRuntime) Login(registry, username, password string) (func(), error) {
+	key := dockerapi.RegistryOf(registry + "/")
+	auth := dockerapi.AuthConfig{Username: username, Password: password, ServerAddress: registry}
+	if key == "docker.io" {
+		auth.ServerAddress = dockerapi.DockerHubAddress
+	}
+	logout := func() {
+		delete(r.auths, key)
+	}
+
+	if err := r.client.Login(auth); err != nil {
+		return logout, fmt.Errorf("ERROR: Error logging in to %s. %s\n", auth.ServerAddress, err.Error())
+	}
+	r.auths[key] = auth
+	return logout, nil
+}
+
+func (r *engineRuntime) Pull(image string) error {
+	util.PrintUtil("INFO: Pulling %s\n", image)
+	return r.client.ImagePull(image, r.auth(image), util.StdErr)
+}
+
+func (r *engineRuntime) Tag(image, tag string) error {
+	util.PrintUtil("INFO: Tagging image %s as %s\n", image, tag)
+	return r.client.ImageTag(image, tag)
+}
+
+func (r *engineRuntime) Push(image string) error {
+	util.PrintUtil("INFO: Pushing %s\n", image)
+	return r.client.ImagePush(image, r.auth(image), util.StdErr)
+}
+
+func (r *engineRuntime) RemoveImage(image string) error {
+	return r.client.ImageRemove(image)
+}
+
+func (r *engineRuntime) StopContainer(name string) error {
+	return r.client.ContainerStop(name)
+}
+
+func (r *engineRuntime) RemoveContainer(name string) error {
+	return r.client.ContainerRemove(name)
+}
+
+func (r *engineRuntime) AddGPUs(spec *RunSpec, gpus int) {
+	addNvidiaGPUs(spec, gpus)
+}
+
+func (r *engineRuntime) HostUser() string {
+	return hostUser(runtime.GOOS, os.Getuid(), os.Getgid())
+}
+
+func (r *engineRuntime) SupportsStorageLimit() bool {
+	if info := r.systemInfo(); info != nil {
+		return storageLimitSupported(info.Driver)
+	}
+	return false
+}
+
+func (r *engineRuntime) HostResources() (*HostResources, error) {
+	info := r.systemInfo()
+	if info == nil {
+		return nil, errors.New("ERROR: Unable to read docker host information.")
+	}
+	return &HostResources{CPUs: info.NCPU, MemoryMiB: float64(info.MemTotal) / (1024 * 1024)}, nil
+}
+
+func (r *engineRuntime) Build(opts BuildOptions) error {
+	context, dockerfile, err := dockerapi.TarContext(opts.ContextDir, opts.Dockerfile)
+	if err != nil {
+		return err
+	}
+	defer context.Close()
+
+	buildOpts := dockerapi.BuildOptions{Dockerfile: dockerfile, Tags: []string{opts.Tag}, Labels: opts.Labels}
+	if opts.CacheFrom != "" {
+		buildOpts.CacheFrom = []string{opts.CacheFrom}
+	}
+	for _, auth := range r.auths {
+		buildOpts.Auths = append(buildOpts.Auths, auth)
+	}
+
+	util.PrintUtil("INFO: Building %s from %s with the Docker Engine API at %s\n", opts.Tag, opts.ContextDir, r.client.Host)
+	return r.client.ImageBuild(context, buildOpts, util.StdErr)
+}
+
+func (r *engineRuntime) Run(spec RunSpec, stdout, stderr io.Writer) error {
+	config, err := runConfig(spec)
+	if err != nil {
+		return err
+	}
+	code, err := r.client.ContainerRun(config, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// runConfig returns the configuration of the container described by spec. The API can't
+// attach a terminal or give the container CDI devices, which docker doesn't use
+func runConfig(spec RunSpec) (dockerapi.RunConfig, error) {
+	if spec.Interactive || spec.Tty || len(spec.Devices) > 0 {
+		return dockerapi.RunConfig{}, errors.New("ERROR: Interactive containers and devices aren't supported by the Docker Engine API client.")
+	}
+
+	config := dockerapi.RunConfig{Name: spec.Name, Remove: spec.Remove}
+	config.Config = dockerapi.ContainerConfig{Image: spec.Image, Cmd: spec.Cmd, User: spec.User}
+	if spec.Entrypoint != "" {
+		config.Config.Entrypoint = []string{spec.Entrypoint}
+	}
+	for _, e := range spec.Env {
+		config.Config.Env = appendEnv(config.Config.Env, e)
+	}
+	if spec.EnvFile != "" {
+		var err error
+		if config.Config.Env, err = readEnvFile(config.Config.Env, spec.EnvFile); err != nil {
+			return config, err
+		}
+	}
+
+	config.Host = dockerapi.HostConfig{Binds: spec.Binds, Memory: spec.MemoryMiB << 20, ShmSize: spec.ShmSizeMiB << 20,
+		NanoCPUs: int64(spec.CPUs * 1e9), Runtime: spec.Runtime}
+	if spec.StorageSizeMiB > 0 {
+		config.Host.StorageOpt = map[string]string{"size": fmt.Sprintf("%dm", spec.StorageSizeMiB)}
+	}
+	return config, nil
+}
+
+// appendEnv adds a NAME=VALUE variable. A NAME without a value is taken from the
+// environment and skipped if it isn't set, as with docker run -e NAME
+func appendEnv(env []string, variable string) []string {
+	if strings.Contains(variable, "=") {
+		return append(env, variable)
+	}
+	if value, ok := os.LookupEnv(variable); ok {
+		return append(env, variable+"="+value)
+	}
+	return env
+}
+
+// readEnvFile adds the variables of a docker env-file. Blank lines and lines starting with #
+// are skipped
+func readEnvFile(env []string, fileName string) ([]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return env, fmt.Errorf("ERROR: Unable to read env-file %s: %s\n", fileName, err.Error())
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		env = appendEnv(env, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return env, fmt.Errorf("ERROR: Unable to read env-file %s: %s\n", fileName, err.Error())
+	}
+	return env, nil
+}
+
+// systemInfo returns the information about the docker host, or nil if it can't be read
+func (r *engineRuntime) systemInfo() *dockerapi.SystemInfo {
+	r.infoOnce.Do(func() {
+		var err error
+		if r.info, err = r.client.Info(); err != nil {
+			util.PrintUtil("WARNING: Unable to read docker host information. %s\n", err.Error())
+		}
+	})
+	return r.info
+}
+
+// auth returns the credentials for the registry of the image, if any
+func (r *engineRuntime) auth(image string) *dockerapi.AuthConfig {
+	if auth, ok := r.auths[dockerapi.RegistryOf(image)]; ok {
+		return &auth
+	}
+	return nil
+}
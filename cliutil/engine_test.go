@@ -0,0 +1,47 @@
+package cliutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRunConfig(t *testing.T) {
+	envFile, _ := ioutil.TempFile("", "seed-env-")
+	envFile.WriteString("# secrets\nSECRET=s3cr3t\n\nPASSED_THROUGH\n")
+	envFile.Close()
+	defer os.Remove(envFile.Name())
+	os.Setenv("PASSED_THROUGH", "value")
+	defer os.Unsetenv("PASSED_THROUGH")
+
+	cases := []struct {
+		spec     RunSpec
+		expected string
+		success  bool
+	}{
+		{RunSpec{Name: "job", Remove: true, Image: "my-image:1.0.0", Cmd: []string{"run", "-v"}},
+			"{job true {my-image:1.0.0 [] [run -v] [] } {[] 0 0 0 map[] }}", true},
+		{RunSpec{Binds: []string{"/in:/in:ro"}, Env: []string{"A=1", "UNSET_VARIABLE"}, EnvFile: envFile.Name(), Image: "image"},
+			"{ false {image [] [] [A=1 SECRET=s3cr3t PASSED_THROUGH=value] } {[/in:/in:ro] 0 0 0 map[] }}", true},
+		{RunSpec{MemoryMiB: 512, ShmSizeMiB: 1024, Runtime: "nvidia", Env: []string{"NVIDIA_VISIBLE_DEVICES=0"}, Image: "image"},
+			"{ false {image [] [] [NVIDIA_VISIBLE_DEVICES=0] } {[] 536870912 1073741824 0 map[] nvidia}}", true},
+		{RunSpec{CPUs: 1.5, StorageSizeMiB: 20, Image: "image"},
+			"{ false {image [] [] [] } {[] 0 0 1500000000 map[size:20m] }}", true},
+		{RunSpec{User: "1000:100", Entrypoint: "sh", Image: "image", Cmd: []string{"id"}},
+			"{ false {image [sh] [id] [] 1000:100} {[] 0 0 0 map[] }}", true},
+		{RunSpec{EnvFile: "/missing/env", Image: "image"}, "", false},
+		{RunSpec{Interactive: true, Tty: true, Image: "image"}, "", false},
+		{RunSpec{Devices: []string{"nvidia.com/gpu=0"}, Image: "image"}, "", false},
+	}
+
+	for _, c := range cases {
+		config, err := runConfig(c.spec)
+		if c.success != (err == nil) {
+			t.Errorf("runConfig(%v) == %v, expected success %v", c.spec.Args(), err, c.success)
+		}
+		if result := fmt.Sprintf("%v", config); err == nil && result != c.expected {
+			t.Errorf("runConfig(%v) == %v, expected %v", c.spec.Args(), result, c.expected)
+		}
+	}
+}
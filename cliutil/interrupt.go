@@ -0,0 +1,114 @@
+package cliutil
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// interrupted is cancelled by the first interrupt signal
+var interrupted, cancelInterrupted = context.WithCancel(context.Background())
+
+// interrupts tracks the interrupt signals received by seed, the commands that stop gracefully
+// when interrupted and the cleanup functions to run if seed exits immediately
+var interrupts = struct {
+	lock     sync.Mutex
+	signals  int
+	watchers int
+	next     int
+	cleanups map[int]func()
+}{cleanups: map[int]func(){}}
+
+//HandleInterrupts stops seed gracefully on Ctrl-C (SIGINT) or SIGTERM. The first signal cancels
+// the context returned by Interrupted so the commands watching it (see WatchInterrupts) can stop
+// their container and write their report. If no command is watching, or a second signal is
+// received, the functions registered with OnInterrupt are run and seed exits immediately
+func HandleInterrupts() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for range signals {
+			interrupts.lock.Lock()
+			interrupts.signals++
+			first, watched := interrupts.signals == 1, interrupts.watchers > 0
+			interrupts.lock.Unlock()
+
+			cancelInterrupted()
+			if first && watched {
+				util.PrintUtil("INFO: Interrupted. Stopping... (interrupt again to exit immediately)\n")
+				continue
+			}
+			runCleanups()
+			os.Exit(constants.CancelledExitCode)
+		}
+	}()
+}
+
+//Interrupted returns a context that is cancelled once seed is interrupted
+func Interrupted() context.Context {
+	return interrupted
+}
+
+//WatchInterrupts marks the caller as stopping gracefully when Interrupted is cancelled, so the
+// first interrupt doesn't exit seed. The returned function ends the watch
+func WatchInterrupts() func() {
+	interrupts.lock.Lock()
+	defer interrupts.lock.Unlock()
+	interrupts.watchers++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			interrupts.lock.Lock()
+			defer interrupts.lock.Unlock()
+			interrupts.watchers--
+		})
+	}
+}
+
+//OnInterrupt registers a function to run if seed exits because it was interrupted, such as one
+// removing temporary files or credentials. The returned function unregisters it
+func OnInterrupt(cleanup func()) func() {
+	interrupts.lock.Lock()
+	defer interrupts.lock.Unlock()
+	id := interrupts.next
+	interrupts.next++
+	interrupts.cleanups[id] = cleanup
+	return func() {
+		interrupts.lock.Lock()
+		defer interrupts.lock.Unlock()
+		delete(interrupts.cleanups, id)
+	}
+}
+
+// runCleanups runs the registered cleanup functions, most recently registered first
+func runCleanups() {
+	interrupts.lock.Lock()
+	var ids []int
+	for id := range interrupts.cleanups {
+		ids = append(ids, id)
+	}
+	cleanups := interrupts.cleanups
+	interrupts.cleanups = map[int]func(){}
+	interrupts.lock.Unlock()
+
+	sort.Ints(ids)
+	for i := len(ids) - 1; i >= 0; i-- {
+		cleanups[ids[i]]()
+	}
+}
+
+// logoutOnInterrupt registers a registry logout to run if seed is interrupted so no credentials
+// are left behind. Returns a function that logs out and unregisters it
+func logoutOnInterrupt(logout func()) func() {
+	remove := OnInterrupt(logout)
+	return func() {
+		remove()
+		logout()
+	}
+}
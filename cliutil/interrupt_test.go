@@ -0,0 +1,40 @@
+package cliutil
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestInterrupts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	var cleaned []int
+	OnInterrupt(func() { cleaned = append(cleaned, 1) })
+	remove := OnInterrupt(func() { cleaned = append(cleaned, 2) })
+	OnInterrupt(func() { cleaned = append(cleaned, 3) })
+	remove()
+
+	// the first interrupt only cancels Interrupted while a command is watching
+	stop := WatchInterrupts()
+	defer stop()
+	HandleInterrupts()
+	p, _ := os.FindProcess(os.Getpid())
+	p.Signal(os.Interrupt)
+	select {
+	case <-Interrupted().Done():
+	case <-time.After(5 * time.Second):
+		t.Errorf("Interrupted() was not cancelled by an interrupt")
+	}
+
+	// cleanups run most recent first, and only once
+	runCleanups()
+	runCleanups()
+	if result := fmt.Sprint(cleaned); result != "[3 1]" {
+		t.Errorf("runCleanups() ran %v, expected %v", result, "[3 1]")
+	}
+}
@@ -0,0 +1,109 @@
+package cliutil
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//RunSpec describes the container a job is run in. Runtimes with a docker compatible command
+// line run it with the arguments returned by Args, while the Docker Engine API client creates
+// the container from the fields themselves
+type RunSpec struct {
+	Name string
+	// Remove removes the container once it exits
+	Remove bool
+	// Interactive attaches the terminal to the container, allocating a tty if Tty is set. Only
+	// the command line can attach a terminal
+	Interactive bool
+	Tty         bool
+	Entrypoint  string
+	// User is the UID[:GID] the container runs as. The user of the image is used if empty
+	User string
+	// Binds are the bind mounts of the container as HOST_PATH:CONTAINER_PATH[:ro|rw]
+	Binds []string
+	// Env holds the NAME=VALUE variables of the container. A NAME without a value is taken
+	// from the environment of seed, as with docker run -e NAME
+	Env []string
+	// EnvFile names a docker env-file of variables that aren't passed on the command line
+	EnvFile    string
+	CPUs       float64
+	MemoryMiB  int64
+	ShmSizeMiB int64
+	// StorageSizeMiB limits the size of the container filesystem if the storage driver of the
+	// runtime can; see ContainerRuntime.SupportsStorageLimit
+	StorageSizeMiB int64
+	// Runtime is the OCI runtime of the container, e.g. nvidia
+	Runtime string
+	// Devices are the devices given to the container, e.g. nvidia.com/gpu=0 for the CDI
+	Devices []string
+	Image   string
+	Cmd     []string
+}
+
+//AddBind bind mounts the host path into the container
+func (s *RunSpec) AddBind(hostPath, containerPath, mode string) {
+	bind := hostPath + ":" + containerPath
+	if mode != "" {
+		bind += ":" + mode
+	}
+	s.Binds = append(s.Binds, bind)
+}
+
+//AddEnv sets the variable in the container
+func (s *RunSpec) AddEnv(name, value string) {
+	s.Env = append(s.Env, name+"="+value)
+}
+
+//Args returns the docker run arguments, without run itself, that run the container
+func (s *RunSpec) Args() []string {
+	var args []string
+	if s.Remove {
+		args = append(args, "--rm")
+	}
+	if s.Interactive {
+		args = append(args, "-i")
+	}
+	if s.Tty {
+		args = append(args, "-t")
+	}
+	if s.Entrypoint != "" {
+		args = append(args, "--entrypoint", s.Entrypoint)
+	}
+	if s.Name != "" {
+		args = append(args, "--name", s.Name)
+	}
+	if s.User != "" {
+		args = append(args, "-u", s.User)
+	}
+	for _, b := range s.Binds {
+		args = append(args, "-v", b)
+	}
+	for _, e := range s.Env {
+		args = append(args, "-e", e)
+	}
+	if s.EnvFile != "" {
+		args = append(args, "--env-file", s.EnvFile)
+	}
+	if s.CPUs > 0 {
+		args = append(args, "--cpus="+strconv.FormatFloat(s.CPUs, 'f', -1, 64))
+	}
+	if s.MemoryMiB > 0 {
+		args = append(args, "-m", fmt.Sprintf("%dm", s.MemoryMiB))
+	}
+	if s.ShmSizeMiB > 0 {
+		args = append(args, fmt.Sprintf("--shm-size=%dm", s.ShmSizeMiB))
+	}
+	if s.StorageSizeMiB > 0 {
+		args = append(args, "--storage-opt", fmt.Sprintf("size=%dm", s.StorageSizeMiB))
+	}
+	if s.Runtime != "" {
+		args = append(args, "--runtime="+s.Runtime)
+	}
+	for _, d := range s.Devices {
+		args = append(args, "--device", d)
+	}
+	if s.Image != "" {
+		args = append(args, s.Image)
+	}
+	return append(args, s.Cmd...)
+}
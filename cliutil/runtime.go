@@ -0,0 +1,529 @@
+package cliutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/dockerapi"
+	common_const "github.com/ngageoint/seed-common/constants"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+//ManifestLabel is the image label holding the seed manifest
+const ManifestLabel = "com.ngageoint.seed.manifest"
+
+//ContainerRuntime is the container engine used to build, run, pull and publish seed images.
+// Commands that stream their output (build, run, images) are executed by the caller using
+// the command and initial arguments returned by CommandArgsInit
+type ContainerRuntime interface {
+	//Name returns the name of the runtime, i.e. docker or podman
+	Name() string
+	//CommandArgsInit returns the initial args and command needed to invoke the runtime
+	CommandArgsInit() ([]string, string)
+	//SupportsLabels returns true if images can be built with labels
+	SupportsLabels() bool
+	//SupportsReferenceFilter returns true if images can be listed with a reference filter
+	SupportsReferenceFilter() bool
+	//ImageExists returns true if the image exists on the local system
+	ImageExists(image string) (bool, error)
+	//ImageLabel returns the value of the given label of a local image
+	ImageLabel(image, label string) (string, error)
+	//Login logs in to a registry using credentials that are isolated from the user's own
+	// logins. The returned function removes the credentials and must always be called
+	Login(registry, username, password string) (func(), error)
+	//Pull pulls an image from a registry
+	Pull(image string) error
+	//Tag tags a local image with a new name
+	Tag(image, tag string) error
+	//Push pushes an image to a registry
+	Push(image string) error
+	//RemoveImage removes a local image
+	RemoveImage(image string) error
+	//StopContainer stops a running container
+	StopContainer(name string) error
+	//RemoveContainer forcibly removes a container
+	RemoveContainer(name string) error
+	//AddGPUs gives the container access to the given number of GPUs
+	AddGPUs(spec *RunSpec, gpus int)
+	//HostUser returns the user a container runs as so the files it writes to bind mounts are
+	// owned by the user running seed, or an empty string if the runtime already does
+	HostUser() string
+	//SupportsStorageLimit returns true if the storage driver can limit the disk space a
+	// container may write to its filesystem
+	SupportsStorageLimit() bool
+	//HostResources returns the capacity of the host containers are run on. This is the virtual
+	// machine when docker or podman run in one
+	HostResources() (*HostResources, error)
+	//Build builds an image, writing the build output to stderr
+	Build(opts BuildOptions) error
+	//Run runs the container described by spec and waits for it to exit. The output of the
+	// container is copied to stdout and stderr. A container that exits with a non-zero code
+	// returns an *ExitError
+	Run(spec RunSpec, stdout, stderr io.Writer) error
+}
+
+//BuildOptions describes an image build
+type BuildOptions struct {
+	// ContextDir is the directory sent to the build
+	ContextDir string
+	// Dockerfile is the path of the Dockerfile. If empty the Dockerfile in ContextDir is used
+	Dockerfile string
+	Tag        string
+	CacheFrom  string
+	Labels     map[string]string
+}
+
+//HostResources describes the capacity of the host containers are run on
+type HostResources struct {
+	CPUs      int
+	MemoryMiB float64
+}
+
+//ExitError is returned by Run when the container exits with a non-zero code
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+var current ContainerRuntime
+
+//NewRuntime returns the container runtime with the given name
+func NewRuntime(name string) (ContainerRuntime, error) {
+	switch name {
+	case constants.DockerRuntime:
+		// talk to the Docker Engine API directly when its socket can be reached. Otherwise fall
+		// back to the docker command line, which may need sudo or a host we don't support
+		if client, err := dockerapi.NewClientFromEnv(); err == nil && client.Reachable() {
+			return newEngineRuntime(client), nil
+		}
+		return &dockerRuntime{cliRuntime{name: name, argsInit: dockerCommandArgsInit, driverFormat: "{{.Driver}}",
+			hostFormat: "{{.NCPU}} {{.MemTotal}}"}}, nil
+	case constants.PodmanRuntime:
+		return &podmanRuntime{cliRuntime: cliRuntime{name: name, argsInit: podmanCommandArgsInit,
+			driverFormat: "{{.Store.GraphDriverName}}", hostFormat: "{{.Host.CPUs}} {{.Host.MemTotal}}"}}, nil
+	}
+	return nil, fmt.Errorf("ERROR: Unknown container runtime %s. Supported runtimes are %s and %s.\n",
+		name, constants.DockerRuntime, constants.PodmanRuntime)
+}
+
+//SetRuntime selects the container runtime used by all commands. If name is empty the
+// runtime named by the SEED_RUNTIME environment variable is used, defaulting to docker
+func SetRuntime(name string) error {
+	if name == "" {
+		name = os.Getenv(constants.RuntimeEnvVar)
+	}
+	if name == "" {
+		name = constants.DockerRuntime
+	}
+
+	r, err := NewRuntime(name)
+	if err != nil {
+		return err
+	}
+	current = r
+	return nil
+}
+
+//Runtime returns the selected container runtime. If none has been selected the runtime is
+// chosen as described by SetRuntime
+func Runtime() ContainerRuntime {
+	if current == nil {
+		if err := SetRuntime(""); err != nil {
+			util.PrintUtil("%s", err.Error())
+			current, _ = NewRuntime(constants.DockerRuntime)
+		}
+	}
+	return current
+}
+
+//SeedFromImageLabel returns the seed manifest stored in the label of a local image
+func SeedFromImageLabel(imageName string) (objects.Seed, error) {
+	label, err := Runtime().ImageLabel(imageName, ManifestLabel)
+	if err != nil {
+		return objects.Seed{}, err
+	}
+	if label == "" {
+		return objects.Seed{}, fmt.Errorf("ERROR: Image %s does not have a %s label.\n", imageName, ManifestLabel)
+	}
+
+	seed, err := objects.SeedFromManifestString(DecodeManifestLabel(label))
+	if err != nil {
+		return seed, fmt.Errorf("ERROR: Unable to parse the seed manifest of image %s: %s\n", imageName, err.Error())
+	}
+	return seed, nil
+}
+
+//DecodeManifestLabel returns the manifest json stored in an image label. Labels written by
+// seed build are a quoted json string with dollar signs escaped for the shell
+func DecodeManifestLabel(label string) string {
+	label = strings.Replace(strings.TrimSpace(label), "\\$", "$", -1)
+	var manifest string
+	if strings.HasPrefix(label, "\"") && json.Unmarshal([]byte(label), &manifest) == nil {
+		return manifest
+	}
+	return label
+}
+
+// cliRuntime implements the ContainerRuntime operations shared by runtimes with a docker
+// compatible command line
+type cliRuntime struct {
+	name     string
+	argsInit func() ([]string, string)
+	// driverFormat is the info template printing the name of the storage driver
+	driverFormat string
+	driverOnce   sync.Once
+	driver       string
+	// hostFormat is the info template printing the number of cpus and bytes of memory of the host
+	hostFormat string
+}
+
+func (r *cliRuntime) Name() string {
+	return r.name
+}
+
+func (r *cliRuntime) CommandArgsInit() ([]string, string) {
+	return r.argsInit()
+}
+
+func (r *cliRuntime) ImageExists(image string) (bool, error) {
+	_, errs, err := r.run(nil, "image", "inspect", image)
+	if err != nil {
+		msg := strings.ToLower(errs)
+		if strings.Contains(msg, "no such image") || strings.Contains(msg, "image not known") {
+			return false, nil
+		}
+		return false, r.error("image inspect", errs, err)
+	}
+	return true, nil
+}
+
+func (r *cliRuntime) ImageLabel(image, label string) (string, error) {
+	format := fmt.Sprintf("{{index .Config.Labels %q}}", label)
+	out, errs, err := r.run(nil, "image", "inspect", "--format", format, image)
+	if err != nil {
+		return "", r.error("image inspect", errs, err)
+	}
+	out = strings.TrimSpace(out)
+	if out == "<no value>" {
+		out = ""
+	}
+	return out, nil
+}
+
+func (r *cliRuntime) login(registry, username, password string) error {
+	args := []string{"login", "-u", username, "--password-stdin"}
+	if registry != "" {
+		args = append(args, registry)
+	}
+	_, errs, err := r.run(strings.NewReader(password), args...)
+	if err != nil {
+		return r.error("login", errs, err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) Pull(image string) error {
+	return r.stream("pull", image)
+}
+
+func (r *cliRuntime) Tag(image, tag string) error {
+	util.PrintUtil("INFO: Tagging image %s as %s\n", image, tag)
+	_, errs, err := r.run(nil, "tag", image, tag)
+	if err != nil {
+		return r.error("tag", errs, err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) Push(image string) error {
+	return r.stream("push", image)
+}
+
+func (r *cliRuntime) RemoveImage(image string) error {
+	_, errs, err := r.run(nil, "rmi", image)
+	if err != nil {
+		return r.error("rmi", errs, err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) StopContainer(name string) error {
+	_, errs, err := r.run(nil, "stop", name)
+	if err != nil {
+		return r.error("stop", errs, err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) RemoveContainer(name string) error {
+	_, errs, err := r.run(nil, "rm", "-f", name)
+	if err != nil {
+		return r.error("rm", errs, err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) SupportsStorageLimit() bool {
+	r.driverOnce.Do(func() {
+		if out, _, err := r.run(nil, "info", "--format", r.driverFormat); err == nil {
+			r.driver = strings.TrimSpace(out)
+		}
+	})
+	return storageLimitSupported(r.driver)
+}
+
+func (r *cliRuntime) HostResources() (*HostResources, error) {
+	out, errs, err := r.run(nil, "info", "--format", r.hostFormat)
+	if err != nil {
+		return nil, r.error("info", errs, err)
+	}
+
+	var cpus int
+	var memory int64
+	if _, err = fmt.Sscan(out, &cpus, &memory); err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to read the host resources from %s info: %s\n", r.name, strings.TrimSpace(out))
+	}
+	return &HostResources{CPUs: cpus, MemoryMiB: float64(memory) / (1024 * 1024)}, nil
+}
+
+// storageLimitSupported returns true for the storage drivers that limit the size of the
+// container filesystem. overlay2 only supports a limit on xfs mounted with pquota, which
+// can't be detected, and devicemapper can't go below the size of the image
+func storageLimitSupported(driver string) bool {
+	switch driver {
+	case "btrfs", "zfs":
+		return true
+	}
+	return false
+}
+
+func (r *cliRuntime) Build(opts BuildOptions) error {
+	args := []string{"build"}
+	// docker doesn't care about validating the cache-from image
+	if opts.CacheFrom != "" {
+		args = append(args, "--cache-from", opts.CacheFrom)
+	}
+	args = append(args, "-t", opts.Tag)
+	if opts.Dockerfile != "" {
+		args = append(args, "-f", opts.Dockerfile)
+	}
+	args = append(args, opts.ContextDir)
+	for _, k := range sortedLabels(opts.Labels) {
+		args = append(args, "--label", k+"="+opts.Labels[k])
+	}
+
+	// the build succeeded if the exit code is zero; progress is written to stderr
+	return r.stream(args...)
+}
+
+func (r *cliRuntime) Run(spec RunSpec, stdout, stderr io.Writer) error {
+	cmdArgs, command := r.argsInit()
+	cmd := exec.Command(command, append(append(cmdArgs, "run"), spec.Args()...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if exitError, ok := err.(*exec.ExitError); ok {
+		return &ExitError{Code: exitError.ExitCode()}
+	}
+	return err
+}
+
+// run executes the runtime with the given arguments and returns its stdout and stderr
+func (r *cliRuntime) run(stdin io.Reader, args ...string) (string, string, error) {
+	cmdArgs, command := r.argsInit()
+	cmd := exec.Command(command, append(cmdArgs, args...)...)
+	var out, errs bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = &out
+	cmd.Stderr = &errs
+	err := cmd.Run()
+	return out.String(), errs.String(), err
+}
+
+// stream executes the runtime with the given arguments, writing its output to stderr
+func (r *cliRuntime) stream(args ...string) error {
+	cmdArgs, command := r.argsInit()
+	cmdArgs = append(cmdArgs, args...)
+	util.PrintUtil("INFO: Running %s command:\n%s %s\n", r.name, command, strings.Join(cmdArgs, " "))
+
+	cmd := exec.Command(command, cmdArgs...)
+	var errs bytes.Buffer
+	if util.StdErr != nil {
+		cmd.Stdout = util.StdErr
+		cmd.Stderr = io.MultiWriter(util.StdErr, &errs)
+	} else {
+		cmd.Stderr = &errs
+	}
+	if err := cmd.Run(); err != nil {
+		return r.error(args[0], errs.String(), err)
+	}
+	return nil
+}
+
+func (r *cliRuntime) error(op, errs string, err error) error {
+	if strings.TrimSpace(errs) != "" {
+		return fmt.Errorf("ERROR: Error executing %s %s.\n%s\n", r.name, op, strings.TrimSpace(errs))
+	}
+	return fmt.Errorf("ERROR: Error executing %s %s. %s\n", r.name, op, err.Error())
+}
+
+// dockerRuntime runs seed images with docker
+type dockerRuntime struct {
+	cliRuntime
+}
+
+func (r *dockerRuntime) SupportsLabels() bool {
+	return util.DockerVersionHasLabel()
+}
+
+func (r *dockerRuntime) SupportsReferenceFilter() bool {
+	return util.DockerVersionHasReferenceFilter()
+}
+
+func (r *dockerRuntime) Login(registry, username, password string) (func(), error) {
+	//set config dir so we don't stomp on other users' logins with sudo
+	configDir := common_const.DockerConfigDir + time.Now().Format(time.RFC3339)
+	os.Setenv(common_const.DockerConfigKey, configDir)
+	logout := func() {
+		util.RemoveAllFiles(configDir)
+		os.Unsetenv(common_const.DockerConfigKey)
+	}
+	return logoutOnInterrupt(logout), r.login(registry, username, password)
+}
+
+func (r *dockerRuntime) AddGPUs(spec *RunSpec, gpus int) {
+	addNvidiaGPUs(spec, gpus)
+}
+
+func (r *dockerRuntime) HostUser() string {
+	return hostUser(runtime.GOOS, os.Getuid(), os.Getgid())
+}
+
+// hostUser returns the UID:GID of the given user to run a container as. Docker Desktop on
+// macOS and Windows already maps bind mounted files to the user, and nothing is needed if
+// seed is run by root, unless it was run with sudo
+func hostUser(goos string, uid, gid int) string {
+	if goos != "linux" || uid < 0 {
+		return ""
+	}
+	if uid == 0 {
+		sudoUID, uidErr := strconv.Atoi(os.Getenv("SUDO_UID"))
+		sudoGID, gidErr := strconv.Atoi(os.Getenv("SUDO_GID"))
+		if uidErr != nil || gidErr != nil || sudoUID == 0 {
+			return ""
+		}
+		uid, gid = sudoUID, sudoGID
+	}
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
+
+// addNvidiaGPUs runs the container with the nvidia container runtime
+func addNvidiaGPUs(spec *RunSpec, gpus int) {
+	var devices []string
+	for g := 0; g < gpus; g++ {
+		devices = append(devices, strconv.Itoa(g))
+	}
+	spec.Runtime = "nvidia"
+	spec.AddEnv("NVIDIA_VISIBLE_DEVICES", strings.Join(devices, ","))
+}
+
+var dockerAccess struct {
+	sync.Once
+	args    []string
+	command string
+}
+
+// dockerCommandArgsInit caches the result of DockerCommandArgsInit so docker info is only
+// run once per invocation of seed
+func dockerCommandArgsInit() ([]string, string) {
+	dockerAccess.Do(func() {
+		dockerAccess.args, dockerAccess.command = DockerCommandArgsInit()
+	})
+	return append([]string{}, dockerAccess.args...), dockerAccess.command
+}
+
+// sortedLabels returns the label names in sorted order so build arguments are stable
+func sortedLabels(labels map[string]string) []string {
+	var names []string
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// podmanRuntime runs seed images with podman. Podman doesn't need sudo: it runs rootless for
+// other users and rootful when run by root
+type podmanRuntime struct {
+	cliRuntime
+	rootlessOnce sync.Once
+	rootless     bool
+}
+
+func (r *podmanRuntime) SupportsLabels() bool {
+	return true
+}
+
+func (r *podmanRuntime) SupportsReferenceFilter() bool {
+	return true
+}
+
+func (r *podmanRuntime) Login(registry, username, password string) (func(), error) {
+	// podman reads and writes registry credentials from the file named by REGISTRY_AUTH_FILE
+	authFile := filepath.Join(os.TempDir(), "seed-auth-"+strconv.FormatInt(time.Now().UnixNano(), 10)+".json")
+	os.Setenv(constants.PodmanAuthFileKey, authFile)
+	logout := func() {
+		os.Remove(authFile)
+		os.Unsetenv(constants.PodmanAuthFileKey)
+	}
+	return logoutOnInterrupt(logout), r.login(registry, username, password)
+}
+
+func (r *podmanRuntime) AddGPUs(spec *RunSpec, gpus int) {
+	// podman exposes GPUs through the nvidia container device interface (CDI)
+	for g := 0; g < gpus; g++ {
+		spec.Devices = append(spec.Devices, fmt.Sprintf("nvidia.com/gpu=%d", g))
+	}
+}
+
+// HostUser returns an empty string for rootless podman, which maps the root user of a
+// container to the user running podman. Rootful podman runs containers as docker does
+func (r *podmanRuntime) HostUser() string {
+	r.rootlessOnce.Do(func() {
+		out, _, err := r.run(nil, "info", "--format", "{{.Host.Security.Rootless}}")
+		r.rootless = podmanRootless(strings.TrimSpace(out), err, os.Getuid())
+	})
+	if r.rootless {
+		return ""
+	}
+	return hostUser(runtime.GOOS, os.Getuid(), os.Getgid())
+}
+
+// podmanRootless returns true if podman info reports that podman runs rootless. If podman info
+// fails podman is taken to be rootless unless it's run by root
+func podmanRootless(info string, err error, uid int) bool {
+	if rootless, parseErr := strconv.ParseBool(info); err == nil && parseErr == nil {
+		return rootless
+	}
+	return uid != 0
+}
+
+func podmanCommandArgsInit() ([]string, string) {
+	return nil, constants.PodmanRuntime
+}
@@ -0,0 +1,225 @@
+package cliutil
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-common/util"
+)
+
+func init() {
+	util.InitPrinter(util.Quiet, nil, nil)
+}
+
+func TestSetRuntime(t *testing.T) {
+	cases := []struct {
+		name     string
+		env      string
+		expected string
+		success  bool
+	}{
+		{"", "", constants.DockerRuntime, true},
+		{"", constants.PodmanRuntime, constants.PodmanRuntime, true},
+		{constants.DockerRuntime, constants.PodmanRuntime, constants.DockerRuntime, true},
+		{constants.PodmanRuntime, "", constants.PodmanRuntime, true},
+		{"rkt", "", "", false},
+	}
+
+	defer os.Unsetenv(constants.RuntimeEnvVar)
+	for _, c := range cases {
+		os.Setenv(constants.RuntimeEnvVar, c.env)
+		current = nil
+		err := SetRuntime(c.name)
+		if c.success != (err == nil) {
+			t.Errorf("SetRuntime(%q) with %s=%q == %v, expected success %v", c.name, constants.RuntimeEnvVar, c.env, err, c.success)
+		}
+		if err == nil && Runtime().Name() != c.expected {
+			t.Errorf("SetRuntime(%q) with %s=%q selected %v, expected %v", c.name, constants.RuntimeEnvVar, c.env,
+				Runtime().Name(), c.expected)
+		}
+	}
+	current = nil
+}
+
+func TestAddGPUs(t *testing.T) {
+	cases := []struct {
+		runtime  string
+		gpus     int
+		expected string
+	}{
+		{constants.DockerRuntime, 2, "[-e NVIDIA_VISIBLE_DEVICES=0,1 --runtime=nvidia]"},
+		{constants.PodmanRuntime, 2, "[--device nvidia.com/gpu=0 --device nvidia.com/gpu=1]"},
+	}
+
+	for _, c := range cases {
+		r, _ := NewRuntime(c.runtime)
+		spec := RunSpec{}
+		r.AddGPUs(&spec, c.gpus)
+		result := fmt.Sprintf("%v", spec.Args())
+		if result != c.expected {
+			t.Errorf("%s AddGPUs(%d) == %v, expected %v", c.runtime, c.gpus, result, c.expected)
+		}
+	}
+}
+
+func TestStorageLimitSupported(t *testing.T) {
+	cases := []struct {
+		driver   string
+		expected bool
+	}{
+		{"btrfs", true},
+		{"zfs", true},
+		{"overlay2", false},
+		{"devicemapper", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		result := storageLimitSupported(c.driver)
+		if result != c.expected {
+			t.Errorf("storageLimitSupported(%q) == %v, expected %v", c.driver, result, c.expected)
+		}
+	}
+}
+
+func TestHostUser(t *testing.T) {
+	defer os.Unsetenv("SUDO_UID")
+	defer os.Unsetenv("SUDO_GID")
+
+	cases := []struct {
+		goos     string
+		uid      int
+		gid      int
+		sudoUID  string
+		expected string
+	}{
+		{"linux", 1000, 100, "", "1000:100"},
+		{"linux", 0, 0, "", ""},
+		{"linux", 0, 0, "1001", "1001:1001"},
+		{"darwin", 501, 20, "", ""},
+		{"windows", -1, -1, "", ""},
+	}
+
+	for _, c := range cases {
+		os.Setenv("SUDO_UID", c.sudoUID)
+		os.Setenv("SUDO_GID", c.sudoUID)
+		result := hostUser(c.goos, c.uid, c.gid)
+		if result != c.expected {
+			t.Errorf("hostUser(%q, %v, %v) == %v, expected %v", c.goos, c.uid, c.gid, result, c.expected)
+		}
+	}
+}
+
+func TestPodmanRootless(t *testing.T) {
+	cases := []struct {
+		info     string
+		err      error
+		uid      int
+		expected bool
+	}{
+		{"true", nil, 1000, true},
+		{"true", nil, 0, true},
+		{"false", nil, 0, false},
+		{"false", nil, 1000, false},
+		{"", errors.New("exit status 125"), 1000, true},
+		{"", errors.New("exit status 125"), 0, false},
+		{"unknown", nil, 0, false},
+	}
+
+	for _, c := range cases {
+		result := podmanRootless(c.info, c.err, c.uid)
+		if result != c.expected {
+			t.Errorf("podmanRootless(%q, %v, %v) == %v, expected %v", c.info, c.err, c.uid, result, c.expected)
+		}
+	}
+}
+
+func TestRunSpecArgs(t *testing.T) {
+	spec := RunSpec{Name: "job", Remove: true, User: "1000:100", Image: "my-seed:1.0.0", Cmd: []string{"run", "a b"},
+		CPUs: 1.5, MemoryMiB: 512, ShmSizeMiB: 64, StorageSizeMiB: 10, EnvFile: "/tmp/env"}
+	spec.AddBind("/data/in.txt", "/data/in.txt", "")
+	spec.AddBind("/data/out", "/out", "rw")
+	spec.AddEnv("INPUT_FILE", "/data/in.txt")
+
+	expected := "[--rm --name job -u 1000:100 -v /data/in.txt:/data/in.txt -v /data/out:/out:rw " +
+		"-e INPUT_FILE=/data/in.txt --env-file /tmp/env --cpus=1.5 -m 512m --shm-size=64m " +
+		"--storage-opt size=10m my-seed:1.0.0 run a b]"
+	result := fmt.Sprintf("%v", spec.Args())
+	if result != expected {
+		t.Errorf("RunSpec.Args() == %v, expected %v", result, expected)
+	}
+}
+
+func TestDecodeManifestLabel(t *testing.T) {
+	cases := []struct {
+		label    string
+		expected string
+	}{
+		{`{"seedVersion":"1.0.0"}`, `{"seedVersion":"1.0.0"}`},
+		{`"{\"seedVersion\":\"1.0.0\",\"command\":\"\$INPUT_FILE \$OUTPUT_DIR\"}"` + "\n",
+			`{"seedVersion":"1.0.0","command":"$INPUT_FILE $OUTPUT_DIR"}`},
+		{`"{\"maintainer\":{\"url\":\"http:\/\/www.example.com\"}}"`, `{"maintainer":{"url":"http://www.example.com"}}`},
+	}
+
+	for _, c := range cases {
+		result := DecodeManifestLabel(c.label)
+		if result != c.expected {
+			t.Errorf("DecodeManifestLabel(%q) == %v, expected %v", c.label, result, c.expected)
+		}
+	}
+}
+
+func TestCliRuntime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// stand-in for the runtime command line that echoes its arguments and fails for missing images
+	tempDir, _ := ioutil.TempDir("", "seed-runtime-")
+	defer os.RemoveAll(tempDir)
+	fake := filepath.Join(tempDir, "fake-runtime")
+	script := "#!/bin/sh\n" +
+		"case \"$*\" in *missing*) echo \"Error: No such image: missing\" >&2; exit 1;; " +
+		"*broken*) echo \"Cannot connect to the daemon\" >&2; exit 1;; " +
+		"*NCPU*) echo \"8 17179869184\"; exit 0;; esac\n" +
+		"echo \"$@\"\n"
+	ioutil.WriteFile(fake, []byte(script), 0755)
+	r := &cliRuntime{name: "fake", argsInit: func() ([]string, string) { return nil, fake }, hostFormat: "{{.NCPU}} {{.MemTotal}}"}
+
+	exists, err := r.ImageExists("addition-job-0.0.1-seed:1.0.0")
+	if !exists || err != nil {
+		t.Errorf("ImageExists(%q) == %v, %v, expected %v, %v", "addition-job-0.0.1-seed:1.0.0", exists, err, true, nil)
+	}
+	exists, err = r.ImageExists("missing")
+	if exists || err != nil {
+		t.Errorf("ImageExists(%q) == %v, %v, expected %v, %v", "missing", exists, err, false, nil)
+	}
+	exists, err = r.ImageExists("broken")
+	if exists || err == nil {
+		t.Errorf("ImageExists(%q) == %v, %v, expected %v and an error", "broken", exists, err, false)
+	}
+
+	label, err := r.ImageLabel("my-seed:1.0.0", ManifestLabel)
+	expected := `image inspect --format {{index .Config.Labels "com.ngageoint.seed.manifest"}} my-seed:1.0.0`
+	if label != expected || err != nil {
+		t.Errorf("ImageLabel(%q) == %v, %v, expected %v", "my-seed:1.0.0", label, err, expected)
+	}
+
+	if err = r.Tag("my-seed:1.0.0", "localhost:5000/my-seed:1.0.0"); err != nil {
+		t.Errorf("Tag(%q, %q) == %v, expected %v", "my-seed:1.0.0", "localhost:5000/my-seed:1.0.0", err, nil)
+	}
+	if err = r.Pull("missing"); err == nil {
+		t.Errorf("Pull(%q) == %v, expected an error", "missing", err)
+	}
+
+	host, err := r.HostResources()
+	if err != nil || *host != (HostResources{CPUs: 8, MemoryMiB: 16384}) {
+		t.Errorf("HostResources() == %v, %v, expected %v", host, err, HostResources{CPUs: 8, MemoryMiB: 16384})
+	}
+}
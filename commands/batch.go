@@ -1,100 +1,420 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 	"gopkg.in/cheggaaa/pb.v1"
 )
 
+//BatchIO holds the arguments of a single row of a batch. Settings and Mounts override the
+// settings and mounts given to the whole batch
 type BatchIO struct {
-	Inputs []string
-	Json   []string
-	Outdir string
+	Inputs   []string
+	Json     []string
+	Outdir   string
+	Settings []string
+	Mounts   []string
 }
 
-func BatchRun(batchDir, batchFile, imageName, manifest, outputDir, metadataSchema string, settings, mounts []string, rmFlag bool) error {
+//BatchResult is the document written by seed batch for the json and yaml formats
+type BatchResult struct {
+	Image     string           `json:"image"`
+	OutputDir string           `json:"outputDir"`
+	Rows      []BatchRowResult `json:"rows"`
+	Failed    int              `json:"failed"`
+	Cancelled bool             `json:"cancelled,omitempty"`
+	StateFile string           `json:"stateFile,omitempty"`
+}
+
+//BatchRowResult records the result of running a single row of a batch. RowDir is the output
+// directory of the row within the batch output directory, while OutputDir is where its last
+// attempt wrote its outputs: a time-stamped subdirectory of RowDir if RowDir wasn't empty (see
+// SetOutputDir), or the URI the outputs were uploaded to
+type BatchRowResult struct {
+	Inputs        []string `json:"inputs"`
+	Json          []string `json:"json"`
+	RowDir        string   `json:"rowDir"`
+	OutputDir     string   `json:"outputDir"`
+	Status        string   `json:"status"`
+	ExitCode      int      `json:"exitCode"`
+	ErrorName     string   `json:"errorName,omitempty"`
+	ErrorCategory string   `json:"errorCategory,omitempty"`
+	Duration      float64  `json:"durationSeconds"`
+	OutputsValid  *bool    `json:"outputsValid,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	Attempts      int      `json:"attempts,omitempty"`
+	Resumed       bool     `json:"resumed,omitempty"`
+}
+
+//BatchOptions describes a batch run by BatchRun. The run options are applied to each row as
+// described by DockerRun; the inputs, json inputs and output directory of each row come from
+// the batch, and its settings and mounts override those of the run options
+type BatchOptions struct {
+	RunOptions
+	// BatchDir is the directory of files to run the image on, one row per file, if no BatchFile is
+	// given. The default is the current directory
+	BatchDir string
+	// BatchFile is the CSV file of the rows of the batch; see ProcessBatchFile
+	BatchFile string
+	// Jobs is the number of rows run at the same time, limited to the number the host has the
+	// cpus and memory for as declared by the seed manifest. A dry run always runs one row at a time
+	Jobs int
+	// Resume skips the rows that succeeded in the previous run of the batch writing to the same
+	// output directory
+	Resume bool
+	// Retries is the number of times a row failing with a job error of the seed manifest is
+	// retried, waiting longer before each retry
+	Retries int
+	// Summary is the file the summary of the rows is written to in SummaryFormat (see
+	// WriteBatchSummary). The default is next to the output directory
+	Summary       string
+	SummaryFormat string
+	// Collect names the file the json outputs of the rows are written to as a table (see
+	// CollectOutputs) in the format given by its extension
+	Collect string
+}
 
-	if imageName == "" {
-		util.PrintUtil("INFO: Image name not specified. Attempting to use manifest: %v\n", manifest)
-		temp, err := objects.GetImageNameFromManifest(manifest, "")
+//BatchRun runs the image once for each row of the batch file or file in the batch
+// directory. For the json and yaml formats a BatchResult with one entry per row is written to stdout
+// If seed is interrupted, the row being run is cancelled, the remaining rows are skipped and the
+// result of the rows run so far is written with cancelled set
+// Each row writes to its own output directory and the results are written in the order of the
+// rows, whichever finishes first. The result of each row is recorded in a state file next to
+// the output directory as it finishes (see BatchState). If rows failed, a BatchFailedError
+// with the number of failed rows is returned
+func BatchRun(opts BatchOptions) error {
+	opts.DryRun = opts.DryRun || opts.Script != nil
+	defer cliutil.WatchInterrupts()()
+
+	if opts.ImageName == "" {
+		util.PrintUtil("INFO: Image name not specified. Attempting to use manifest: %v\n", opts.Manifest)
+		temp, err := objects.GetImageNameFromManifest(opts.Manifest, "")
 		if err != nil {
 			return err
 		}
-		imageName = temp
+		opts.ImageName = temp
 	}
 
-	if imageName == "" {
+	if opts.ImageName == "" {
 		return errors.New("ERROR: No input image specified.")
 	}
 
-	if exists, err := util.ImageExists(imageName); !exists {
-		msg := fmt.Sprintf("Unable to find image: %s. Did you specify a valid tag?", imageName)
+	if exists, err := cliutil.Runtime().ImageExists(opts.ImageName); !exists {
+		msg := fmt.Sprintf("Unable to find image: %s. Did you specify a valid tag?", opts.ImageName)
 		util.PrintUtil("%s\n", msg)
 		return err
 	}
 
-	if batchDir == "" {
-		batchDir = "."
+	if opts.BatchDir == "" {
+		opts.BatchDir = "."
 	}
 
-	batchDir = util.GetFullPath(batchDir, "")
+	opts.BatchDir = util.GetFullPath(opts.BatchDir, "")
 
-	seed := objects.SeedFromImageLabel(imageName)
+	seed, err := cliutil.SeedFromImageLabel(opts.ImageName)
+	if err != nil {
+		util.PrintUtil("%s", err.Error())
+		return err
+	}
 
-	outdir := getOutputDir(outputDir, imageName)
+	if opts.Resume && opts.OutputDir == "" {
+		return errors.New("ERROR: The output directory (-o) of the batch to resume must be given.")
+	}
+	if opts.DryRun {
+		if err = CheckDryRunOutputDir(opts.OutputDir); err != nil {
+			return err
+		}
+	}
+	outdir := getOutputDir(opts.OutputDir, opts.ImageName)
 
 	var inputs []BatchIO
-	var err error
 
-	if batchFile != "" {
-		inputs, err = ProcessBatchFile(seed, batchFile, outdir)
+	if opts.BatchFile != "" {
+		inputs, err = ProcessBatchFile(seed, opts.BatchFile, outdir)
 		if err != nil {
-			util.PrintUtil("ERROR: Error processing batch file: %s\n", batchFile)
+			util.PrintUtil("ERROR: Error processing batch file: %s\n", opts.BatchFile)
 			return err
 		}
 	} else {
-		inputs, err = ProcessDirectory(seed, batchDir, outdir)
+		inputs, err = ProcessDirectory(seed, opts.BatchDir, outdir)
 		if err != nil {
-			util.PrintUtil("ERROR: Error processing batch directory: %s\n", batchDir)
+			util.PrintUtil("ERROR: Error processing batch directory: %s\n", opts.BatchDir)
+			return err
+		}
+	}
+
+	if err = ValidateBatch(&seed, inputs, opts.Settings, opts.Mounts); err != nil {
+		return err
+	}
+
+	// dry runs don't finish any rows
+	var state *BatchState
+	if !opts.DryRun {
+		if state, err = OpenBatchState(outdir, opts.Resume); err != nil {
 			return err
 		}
+		defer state.Close()
+		util.PrintUtil("INFO: Recording the batch state in %s\n", state.File)
 	}
 
+	// rows are run by the workers in any order but their results are kept in the order of the rows
+	rows := make([]*BatchRowResult, len(inputs))
+	var pending []int
+	for i, in := range inputs {
+		if prev, ok := state.Succeeded(in, overrideArgs(opts.Settings, in.Settings), overrideArgs(opts.Mounts, in.Mounts)); ok {
+			prev.Resumed = true
+			rows[i] = &prev
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	if opts.Resume {
+		util.PrintUtil("INFO: Skipping %d rows that succeeded in the previous run of the batch\n", len(inputs)-len(pending))
+	}
+
+	// the commands of a dry run are printed or written to the script in the order of the rows
+	workers := 1
+	if opts.Jobs > 1 && !opts.DryRun {
+		host, hostErr := cliutil.Runtime().HostResources()
+		if hostErr != nil {
+			util.PrintUtil("WARNING: Unable to determine the capacity of the %s host; running %d rows at a time.\n%s\n",
+				cliutil.Runtime().Name(), opts.Jobs, hostErr.Error())
+		}
+		workers = batchWorkers(&seed, opts.Jobs, host)
+		if workers < opts.Jobs {
+			util.PrintUtil("INFO: The host only has the cpus and memory to run %d rows at a time.\n", workers)
+		}
+	}
+
+	// the resolved commands of a dry run are printed unless they are written to a script
+	util.InitPrinter(util.PrintErr, os.Stderr, os.Stderr)
+	if !opts.DryRun || opts.Script != nil {
+		util.InitPrinter(util.Quiet, nil, nil)
+	}
+
+	var lock sync.Mutex
+	next := make(chan int)
+	var wg sync.WaitGroup
+
 	bar := pb.StartNew(len(inputs))
 	bar.Output = os.Stderr
+	bar.Add(len(inputs) - len(pending))
 	defer bar.Finish()
-	for _, in := range inputs {
-		exitCode, err := DockerRun(imageName, manifest, in.Outdir, metadataSchema, in.Inputs, in.Json, settings, mounts, rmFlag, true)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				in := inputs[i]
+
+				truncatedInputs := truncateInputs(in.Inputs)
+
+				// the rows of a batch don't read a job parameter file, stop for a shell or keep their containers
+				run := opts.RunOptions
+				run.OutputDir, run.Inputs, run.Json = in.Outdir, in.Inputs, in.Json
+				run.Settings, run.Mounts = overrideArgs(opts.Settings, in.Settings), overrideArgs(opts.Mounts, in.Mounts)
+				run.Format, run.Params, run.Shell, run.KeepOnFailure = constants.TextFormat, "", false, false
+				run.logStderrOnly = workers > 1
+
+				var exitCode, attempt int
+				var err error
+				var report RunReport
+				start := time.Now()
+				for attempt = 1; ; attempt++ {
+					report = RunReport{}
+					exitCode, err = dockerRun(run, &report)
+					if attempt > opts.Retries || !retryable(&seed, err, exitCode) {
+						break
+					}
+
+					delay := retryDelay(attempt)
+					lock.Lock()
+					fmt.Fprintf(os.Stderr, "RETRY: Input = %v \t ExitCode = %d \t Retry %d of %d in %v \n", truncatedInputs, exitCode, attempt, opts.Retries, delay)
+					lock.Unlock()
+					select {
+					case <-time.After(delay):
+					case <-cliutil.Interrupted().Done():
+					}
+					if cliutil.Interrupted().Err() != nil {
+						exitCode, err = constants.CancelledExitCode, ErrCancelled
+						break
+					}
+				}
+
+				row := BatchRowResult{Inputs: in.Inputs, Json: in.Json, RowDir: in.Outdir, OutputDir: rowOutputDir(in, &report),
+					Status: RunStatusSuccess, ExitCode: exitCode, Duration: time.Since(start).Seconds()}
+				if report.Error != nil {
+					row.ErrorName, row.ErrorCategory = report.Error.Name, report.Error.Category
+				}
+				if report.Outputs != nil {
+					valid := report.Outputs.Valid
+					row.OutputsValid = &valid
+				}
+				if opts.DryRun && err == nil {
+					row.Status = RunStatusDryRun
+				} else if err == ErrJobTimeout {
+					row.Status = RunStatusTimeout
+				} else if err == ErrCancelled {
+					row.Status = RunStatusCancelled
+				} else if err != nil {
+					row.Status = RunStatusFailed
+				}
+				if err != nil {
+					row.Error = strings.TrimSpace(err.Error())
+				}
+				if opts.Retries > 0 {
+					row.Attempts = attempt
+				}
+
+				lock.Lock()
+				rows[i] = &row
+				// cancelled rows aren't recorded so they are run again when the batch is resumed
+				if state != nil && row.Status != RunStatusCancelled {
+					if stateErr := state.Record(row, run.Settings, run.Mounts); stateErr != nil {
+						fmt.Fprintf(os.Stderr, "WARNING: Unable to record the batch state in %s: %s\n", state.File, stateErr.Error())
+					}
+				}
+				if err == ErrJobTimeout {
+					fmt.Fprintf(os.Stderr, "TIMEOUT: Input = %v \t ExitCode = %d \t Error = %s \n", truncatedInputs, exitCode, err.Error())
+				} else if err != nil && err != ErrCancelled {
+					fmt.Fprintf(os.Stderr, "FAIL: Input = %v \t ExitCode = %d \t Error = %s \n", truncatedInputs, exitCode, err.Error())
+				}
+				lock.Unlock()
+				bar.Increment()
+			}
+		}()
+	}
 
-		//trim inputs to print only the key values and filenames
-		truncatedInputs := []string{}
-		for _, i := range in.Inputs {
-			begin := strings.Index(i, "=") + 1
-			end := strings.LastIndex(i, "/")
-			truncatedInputs = append(truncatedInputs, i[0:begin]+"..."+i[end:])
+	// once seed is interrupted the rows that haven't started are skipped
+	for _, i := range pending {
+		if cliutil.Interrupted().Err() != nil {
+			break
 		}
+		select {
+		case next <- i:
+		case <-cliutil.Interrupted().Done():
+		}
+	}
+	close(next)
+	wg.Wait()
+	util.InitPrinter(util.PrintErr, os.Stderr, os.Stderr)
 
-		if err != nil {
-			msg := fmt.Sprintf("FAIL: Input = %v \t ExitCode = %d \t Error = %s \n", truncatedInputs, exitCode, err.Error())
-			util.InitPrinter(util.PrintErr, os.Stderr, os.Stderr)
-			util.PrintUtil("%v", msg)
+	result := BatchResult{Image: opts.ImageName, OutputDir: outdir, Rows: []BatchRowResult{}}
+	if state != nil {
+		result.StateFile = state.File
+	}
+	for _, row := range rows {
+		if row != nil {
+			result.Rows = append(result.Rows, *row)
+		}
+		if row == nil || row.Status == RunStatusCancelled {
+			result.Cancelled = true
+		} else if row.Status == RunStatusFailed || row.Status == RunStatusTimeout {
+			result.Failed++
 		}
+	}
 
-		bar.Increment()
-		time.Sleep(time.Second)
+	message := "Batch complete"
+	if result.Cancelled {
+		message = fmt.Sprintf("Batch cancelled after %d of %d rows", len(result.Rows), len(inputs))
+	} else if result.Failed > 0 {
+		message = fmt.Sprintf("Batch complete; %d of %d rows failed", result.Failed, len(inputs))
 	}
+	// dry runs only have a summary if one is asked for
+	if opts.Summary != "" || !opts.DryRun {
+		if opts.Summary == "" {
+			opts.Summary = BatchSummaryFile(outdir, opts.SummaryFormat)
+		}
+		if err = WriteBatchSummary(opts.Summary, opts.SummaryFormat, &result); err != nil {
+			bar.FinishPrint(message)
+			return err
+		}
+		message += "; summary written to " + opts.Summary
+	}
+	bar.FinishPrint(message)
 
-	bar.FinishPrint("Batch complete")
-	return err
+	if opts.Collect != "" && !opts.DryRun {
+		if err = CollectBatchOutputs(&seed, opts.ImageName, result.Rows, opts.Collect, OutputTableFormat(opts.Collect)); err != nil {
+			return err
+		}
+		util.PrintUtil("INFO: Collected the json outputs of the rows in %s\n", opts.Collect)
+	}
+
+	if err = WriteResult(opts.Format, result); err != nil {
+		return err
+	}
+	if result.Cancelled {
+		return ErrCancelled
+	}
+	if result.Failed > 0 {
+		return &BatchFailedError{Failed: result.Failed, Total: len(inputs)}
+	}
+	return nil
+}
+
+// truncateInputs trims the inputs of a row to their keys and file names for printing
+func truncateInputs(inputs []string) []string {
+	truncated := []string{}
+	for _, input := range inputs {
+		begin := strings.Index(input, "=") + 1
+		value := input[begin:]
+		if name := filepath.Base(value); value != "" && name != value {
+			value = "..." + string(filepath.Separator) + name
+		}
+		truncated = append(truncated, input[:begin]+value)
+	}
+	return truncated
+}
+
+// batchWorkers returns the number of rows of a batch to run at the same time: jobs, limited to
+// the number of containers the host has the cpus and memory for. The input multipliers of the
+// resources aren't known before the rows are run, so only the constant amounts are counted.
+// host is nil if its capacity isn't known
+func batchWorkers(seed *objects.Seed, jobs int, host *cliutil.HostResources) int {
+	workers := jobs
+	if workers < 1 {
+		workers = 1
+	}
+	if host == nil {
+		return workers
+	}
+
+	for _, s := range seed.Job.Resources.Scalar {
+		amount := scalarAmount(s, 0)
+		available := 0.0
+		switch s.Name {
+		case "cpus":
+			available = float64(host.CPUs)
+		case "mem":
+			available = host.MemoryMiB
+		default:
+			continue
+		}
+		if amount <= 0 {
+			continue
+		}
+		// a row the host can't run at all is still run on its own to be refused (or forced) by DockerRun
+		if fit := int(math.Max(math.Floor(available/amount), 1)); fit < workers {
+			workers = fit
+		}
+	}
+	return workers
 }
 
 //PrintBatchUsage prints the seed batch usage arguments, then exits the program
@@ -108,10 +428,22 @@ func PrintBatchUsage() {
 		constants.ShortImgNameFlag, constants.ImgNameFlag)
 	util.PrintUtil("  -%s -%s\t  Manifest file to use if an image name is not specified (default is seed.manifest.json within the current directory).\n",
 		constants.ShortManifestFlag, constants.ManifestFlag)
-	util.PrintUtil("  -%s  -%s \t Optional file specifying input keys and file mapping for batch processing. Supersedes directory flag.\n",
+	util.PrintUtil("  -%s  -%s \t Optional CSV file of input keys and file mapping for batch processing; columns may also be json:KEY, setting:KEY or mount:KEY. Supersedes directory flag.\n",
 		constants.ShortBatchFlag, constants.BatchFlag)
 	util.PrintUtil("  -%s  -%s Alternative to batch file.  Specifies a directory of files to batch process (default is current directory).\n",
 		constants.ShortJobDirectoryFlag, constants.JobDirectoryFlag)
+	util.PrintUtil("  -%s \t Number of rows to run at the same time, limited by the cpus and memory of the host (default is 1)\n",
+		constants.JobsFlag)
+	util.PrintUtil("  -%s \t Skip the rows that succeeded in the previous run of the batch writing to the same output directory\n",
+		constants.ResumeFlag)
+	util.PrintUtil("  -%s \t Number of times to retry a row that fails with a job error of the seed manifest (default is 0)\n",
+		constants.RetryFlag)
+	util.PrintUtil("  -%s \t File to write the summary of the rows to (default is next to the output directory)\n",
+		constants.SummaryFlag)
+	util.PrintUtil("  -%s \t Format of the summary: %s, %s or %s (default is %s)\n",
+		constants.SummaryFormatFlag, constants.CsvFormat, constants.JsonFormat, constants.JunitFormat, constants.CsvFormat)
+	util.PrintUtil("  -%s \t File to write a table of the json outputs of the rows to: CSV, or json for files ending in .json\n",
+		constants.CollectFlag)
 	util.PrintUtil("  -%s \t\t Automatically remove the container when it exits (docker run --rm)\n",
 		constants.RmFlag)
 	util.PrintUtil("  -%s  -%s \t Specifies the key/value setting values of the seed spec in the format SETTING_KEY=VALUE\n",
@@ -122,10 +454,45 @@ func PrintBatchUsage() {
 		constants.ShortJobOutputDirFlag, constants.JobOutputDirFlag)
 	util.PrintUtil("  -%s  -%s \t External Seed metadata schema file; Overrides built in schema to validate side-car metadata files\n",
 		constants.ShortSchemaFlag, constants.SchemaFlag)
+	util.PrintUtil("  -%s \t Stop each run after the given number of seconds (default is job.timeout from the seed manifest)\n",
+		constants.TimeoutFlag)
+	util.PrintUtil("  -%s \t Fail a run if required outputs are missing or seed.outputs.json or side-car metadata files are invalid\n",
+		constants.StrictFlag)
+	util.PrintUtil("  -%s \t Fail instead of warning when input files don't match the media types declared in the seed manifest\n",
+		constants.StrictMediaTypesFlag)
+	util.PrintUtil("  -%s \t Format of the batch results written to stdout: text, json or yaml (default is text)\n",
+		constants.FormatFlag)
+	util.PrintUtil("  -%s \t Resolve and print the docker command of each row without starting any containers\n",
+		constants.DryRunFlag)
+	util.PrintUtil("  -%s \t Write a shell script that reproduces the batch without the seed CLI (implies -%s)\n",
+		constants.EmitScriptFlag, constants.DryRunFlag)
+	util.PrintUtil("  -%s \t Run each row even if the host doesn't have the cpus, memory or disk space it requires\n",
+		constants.ForceRunFlag)
+	util.PrintUtil("  -%s \t User to run the job containers as: %s, %s, %s or UID[:GID] (default is %s)\n",
+		constants.RunAsFlag, constants.HostUser, constants.ImageUser, constants.RootUser, constants.HostUser)
+	util.PrintUtil("  -%s \t Container runtime to use: docker or podman (default is $%s or docker)\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
 	return
 }
 
+// rowOutputDir returns the directory the last attempt of a row wrote its outputs to, as
+// recorded by its run report. The row directory is returned if the container wasn't started
+func rowOutputDir(in BatchIO, report *RunReport) string {
+	switch {
+	case report.UploadedTo != "":
+		return report.UploadedTo
+	case report.OutputDir != "":
+		return report.OutputDir
+	}
+	return in.Outdir
+}
+
+// getOutputDir returns the batch output directory, creating it if needed. The outputs of each
+// row are uploaded to an output directory given as a URI
 func getOutputDir(outputDir, imageName string) string {
+	if remote.IsURI(outputDir) {
+		return outputDir
+	}
 	if outputDir == "" {
 		outputDir = "batch-" + imageName + "-" + time.Now().Format(time.RFC3339)
 		outputDir = strings.Replace(outputDir, ":", "_", -1)
@@ -180,12 +547,12 @@ func ProcessDirectory(seed objects.Seed, batchDir, outdir string) ([]BatchIO, er
 		if file.IsDir() {
 			continue
 		}
-		fileDir := filepath.Join(outdir, file.Name())
+		fileDir := remote.Join(outdir, file.Name())
 		filePath := filepath.Join(batchDir, file.Name())
 		fileInputs := []string{}
 		jsonInputs := []string{}
 		fileInputs = append(fileInputs, key+"="+filePath)
-		row := BatchIO{fileInputs, jsonInputs, fileDir}
+		row := BatchIO{Inputs: fileInputs, Json: jsonInputs, Outdir: fileDir}
 		batchIO = append(batchIO, row)
 	}
 
@@ -194,62 +561,234 @@ func ProcessDirectory(seed objects.Seed, batchDir, outdir string) ([]BatchIO, er
 	return batchIO, err
 }
 
+//ProcessBatchFile reads the rows of a CSV (RFC 4180) batch file. The first record names the
+// column of each value: a file input (NAME or input:NAME), a json input (json:NAME), a setting
+// (setting:NAME) or a mount (mount:NAME) of the seed manifest. Values are trimmed and empty
+// values aren't given to the row. The columns and every row are checked against the seed
+// manifest before any row is returned
 func ProcessBatchFile(seed objects.Seed, batchFile, outdir string) ([]BatchIO, error) {
-	lines, err := util.ReadLinesFromFile(batchFile)
+	data, err := ioutil.ReadFile(batchFile)
 	if err != nil {
 		return nil, err
 	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
 
-	if len(lines) == 0 {
+	if len(bytes.TrimSpace(data)) == 0 {
 		return nil, errors.New("ERROR: Empty batch file")
 	}
-
-	keys := strings.Split(lines[0], ",")
-	extraKeys := keys
-
-	if len(keys) == 0 || len(keys[0]) == 0 {
+	if firstLine := strings.SplitN(string(data), "\n", 2)[0]; strings.TrimSpace(firstLine) == "" {
 		return nil, errors.New("ERROR: Empty keys list on first line of batch file.")
 	}
 
-	for _, f := range seed.Job.Interface.Inputs.Files {
-		hasKey := util.ContainsString(keys, f.Name)
-		if f.Required && !hasKey {
-			msg := fmt.Sprintf("ERROR: Batch file is missing required key %v", f.Name)
-			return nil, errors.New(msg)
-		} else if !hasKey {
-			util.PrintUtil("WARN: Missing input for key " + f.Name)
-		}
-		extraKeys = util.RemoveString(extraKeys, f.Name)
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Invalid batch file %s: %s\n", batchFile, err.Error())
 	}
-
-	if len(extraKeys) > 0 {
-		msg := fmt.Sprintf("WARN: These input keys don't match any specified keys in the Seed manifest: %v\n", extraKeys)
-		util.PrintUtil(msg)
+	columns, err := batchColumns(&seed, header)
+	if err != nil {
+		return nil, err
 	}
 
 	batchIO := []BatchIO{}
-	for i, line := range lines {
-		if i == 0 || len(line) == 0 {
-			continue
+	for i := 1; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ERROR: Invalid batch file %s: %s\n", batchFile, err.Error())
 		}
-		values := strings.Split(line, ",")
-		fileInputs := []string{}
-		jsonInputs := []string{}
+
+		row := BatchIO{}
 		inputNames := fmt.Sprintf("%d", i)
-		for j, file := range values {
-			if j > len(keys) {
-				util.PrintUtil("WARN: More files provided than keys")
+		empty := true
+		for j, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			empty = false
+			arg := columns[j].name + "=" + value
+			switch columns[j].kind {
+			case batchInputColumn:
+				row.Inputs = append(row.Inputs, arg)
+				inputNames += "-" + filepath.Base(value)
+			case batchJsonColumn:
+				row.Json = append(row.Json, arg)
+			case batchSettingColumn:
+				row.Settings = append(row.Settings, arg)
+			case batchMountColumn:
+				row.Mounts = append(row.Mounts, arg)
 			}
-			fileInputs = append(fileInputs, keys[j]+"="+file)
-			inputNames += "-" + filepath.Base(file)
 		}
-		fileDir := filepath.Join(outdir, inputNames)
-		row := BatchIO{fileInputs, jsonInputs, fileDir}
+		if empty {
+			continue
+		}
+		row.Outdir = remote.Join(outdir, inputNames)
 		batchIO = append(batchIO, row)
 	}
 
 	util.PrintUtil("Batch Input = %s \t", batchFile)
 	util.PrintUtil("Batch Output Dir = %s \n", outdir)
 
-	return batchIO, err
+	return batchIO, nil
+}
+
+// the kinds of value a column of a batch file may hold, given as a prefix of the column name
+const (
+	batchInputColumn   = "input"
+	batchJsonColumn    = "json"
+	batchSettingColumn = "setting"
+	batchMountColumn   = "mount"
+)
+
+// batchColumn is the input, json input, setting or mount of the seed manifest a column of a
+// batch file gives the values of
+type batchColumn struct {
+	kind string
+	name string
+}
+
+// batchColumns matches the columns of the header of a batch file with the interface of the
+// seed manifest. A column without a kind prefix names a file input. Only multiple file inputs
+// may be given by more than one column
+func batchColumns(seed *objects.Seed, header []string) ([]batchColumn, error) {
+	declared := map[string]map[string]string{
+		batchInputColumn:   {},
+		batchJsonColumn:    {},
+		batchSettingColumn: {},
+		batchMountColumn:   {},
+	}
+	multiple := map[string]bool{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		declared[batchInputColumn][util.GetNormalizedVariable(f.Name)] = f.Name
+		multiple[f.Name] = f.Multiple
+	}
+	for _, j := range seed.Job.Interface.Inputs.Json {
+		declared[batchJsonColumn][util.GetNormalizedVariable(j.Name)] = j.Name
+	}
+	for _, s := range seed.Job.Interface.Settings {
+		declared[batchSettingColumn][util.GetNormalizedVariable(s.Name)] = s.Name
+	}
+	for _, m := range seed.Job.Interface.Mounts {
+		declared[batchMountColumn][util.GetNormalizedVariable(m.Name)] = m.Name
+	}
+
+	var columns []batchColumn
+	var errs []string
+	given := map[batchColumn]bool{}
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		kind, key := batchInputColumn, h
+		if x := strings.SplitN(h, ":", 2); len(x) == 2 {
+			kind, key = strings.ToLower(strings.TrimSpace(x[0])), strings.TrimSpace(x[1])
+		}
+		names, ok := declared[kind]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("column %d: unknown kind %s in %q, expected input, json, setting or mount", i+1, kind, h))
+			columns = append(columns, batchColumn{})
+			continue
+		}
+		name, ok := names[util.GetNormalizedVariable(key)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("column %d: %s is not %s of the seed manifest", i+1, h, batchColumnKind(kind)))
+			columns = append(columns, batchColumn{})
+			continue
+		}
+		column := batchColumn{kind, name}
+		if given[column] && !(kind == batchInputColumn && multiple[name]) {
+			errs = append(errs, fmt.Sprintf("column %d: %s is given by more than one column", i+1, h))
+		}
+		given[column] = true
+		columns = append(columns, column)
+	}
+
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		if f.Required && !given[batchColumn{batchInputColumn, f.Name}] {
+			errs = append(errs, fmt.Sprintf("Batch file is missing required key %v", f.Name))
+		}
+	}
+	for _, j := range seed.Job.Interface.Inputs.Json {
+		if j.Required && !given[batchColumn{batchJsonColumn, j.Name}] {
+			errs = append(errs, fmt.Sprintf("Batch file is missing required key %s:%v", batchJsonColumn, j.Name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("ERROR: The columns of the batch file don't match the seed manifest:\n  %s\n", strings.Join(errs, "\n  "))
+	}
+	return columns, nil
+}
+
+// batchColumnKind describes the kind of a batch file column for error messages
+func batchColumnKind(kind string) string {
+	switch kind {
+	case batchJsonColumn:
+		return "a json input"
+	case batchSettingColumn:
+		return "a setting"
+	case batchMountColumn:
+		return "a mount"
+	}
+	return "an input file"
+}
+
+// maxBatchErrors is the number of row errors listed by ValidateBatch
+const maxBatchErrors = 20
+
+//ValidateBatch checks every row of a batch against the seed manifest before any is run: the
+// required inputs and json inputs must be given, json inputs must be of their declared types
+// and every setting and mount must be given by the row or by the settings and mounts of the batch
+func ValidateBatch(seed *objects.Seed, rows []BatchIO, settings, mounts []string) error {
+	var errs []string
+	for _, row := range rows {
+		label := filepath.Base(row.Outdir)
+		inputs := inputValues(row.Inputs, false)
+		for _, f := range seed.Job.Interface.Inputs.Files {
+			if f.Required && len(inputs[f.Name]) == 0 {
+				errs = append(errs, fmt.Sprintf("%s: missing value for required input %s", label, f.Name))
+			}
+		}
+
+		jsonValues := inputMap(row.Json, false)
+		for _, j := range seed.Job.Interface.Inputs.Json {
+			value, ok := jsonValues[j.Name]
+			if !ok {
+				if j.Required {
+					errs = append(errs, fmt.Sprintf("%s: missing value for required json input %s", label, j.Name))
+				}
+				continue
+			}
+			// files given as URIs are only checked once they are staged by the run
+			prefix := constants.JsonFilePrefix
+			if strings.HasPrefix(value, prefix) && remote.IsURI(value[len(prefix):]) {
+				continue
+			}
+			if _, err := jsonInputValue(j, value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", label, err.Error()))
+			}
+		}
+
+		rowSettings := inputMap(overrideArgs(settings, row.Settings), true)
+		for _, s := range seed.Job.Interface.Settings {
+			if _, ok := rowSettings[util.GetNormalizedVariable(s.Name)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing value for setting %s", label, s.Name))
+			}
+		}
+		rowMounts := inputMap(overrideArgs(mounts, row.Mounts), false)
+		for _, m := range seed.Job.Interface.Mounts {
+			if _, ok := rowMounts[m.Name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing value for mount %s", label, m.Name))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxBatchErrors {
+		errs = append(errs[:maxBatchErrors], fmt.Sprintf("... and %d more", len(errs)-maxBatchErrors))
+	}
+	return fmt.Errorf("ERROR: The batch rows don't match the seed manifest:\n  %s\n", strings.Join(errs, "\n  "))
 }
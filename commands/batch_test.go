@@ -2,10 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 )
@@ -23,10 +26,16 @@ func TestProcessDirectory(t *testing.T) {
 		expectedErrorMsg string
 	}{
 		{"../testdata", "../testdata/test-extract", "../examples/extractor/seed.manifest.json",
-			"[{[ZIP=../testdata/batch-test.csv] [] ../testdata/test-extract/batch-test.csv} " +
-				"{[ZIP=../testdata/empty-batch.csv] [] ../testdata/test-extract/empty-batch.csv} " +
-				"{[ZIP=../testdata/missing-keys.csv] [] ../testdata/test-extract/missing-keys.csv} " +
-				"{[ZIP=../testdata/seed-scale.zip] [] ../testdata/test-extract/seed-scale.zip}]",
+			"[{[ZIP=../testdata/batch-test.csv] [] ../testdata/test-extract/batch-test.csv [] []} " +
+				"{[ZIP=../testdata/empty-batch.csv] [] ../testdata/test-extract/empty-batch.csv [] []} " +
+				"{[ZIP=../testdata/missing-keys.csv] [] ../testdata/test-extract/missing-keys.csv [] []} " +
+				"{[ZIP=../testdata/seed-scale.zip] [] ../testdata/test-extract/seed-scale.zip [] []}]",
+			""},
+		{"../testdata", "s3://bucket/extract/", "../examples/extractor/seed.manifest.json",
+			"[{[ZIP=../testdata/batch-test.csv] [] s3://bucket/extract/batch-test.csv [] []} " +
+				"{[ZIP=../testdata/empty-batch.csv] [] s3://bucket/extract/empty-batch.csv [] []} " +
+				"{[ZIP=../testdata/missing-keys.csv] [] s3://bucket/extract/missing-keys.csv [] []} " +
+				"{[ZIP=../testdata/seed-scale.zip] [] s3://bucket/extract/seed-scale.zip [] []}]",
 			""},
 		{"../testdata", "../testdata/test-multiple", "../testdata/multiple-required-inputs/seed.manifest.json",
 			"[]", "ERROR: Multiple required inputs are not supported when batch processing directories."},
@@ -60,9 +69,9 @@ func TestProcessBatchFile(t *testing.T) {
 		expectedErrorMsg string
 	}{
 		{"../testdata/batch-test.csv", "../testdata/test-extract-file", "../examples/extractor/seed.manifest.json",
-			"[{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test1.zip] [] ../testdata/test-extract-file/1-test1.zip} " +
-				"{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test2.zip] [] ../testdata/test-extract-file/2-test2.zip} " +
-				"{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test3.zip] [] ../testdata/test-extract-file/3-test3.zip}]",
+			"[{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test1.zip] [] ../testdata/test-extract-file/1-test1.zip [] []} " +
+				"{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test2.zip] [] ../testdata/test-extract-file/2-test2.zip [] []} " +
+				"{[ZIP=/home/jtobe/go/src/github.com/ngageoint/seed-cli/testdata/test3.zip] [] ../testdata/test-extract-file/3-test3.zip [] []}]",
 			""},
 		{"../testdata/empty-batch.csv", "../testdata/test-empty", "../testdata/multiple-required-inputs/seed.manifest.json",
 			"[]", "ERROR: Empty batch file"},
@@ -86,3 +95,126 @@ func TestProcessBatchFile(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchWorkers(t *testing.T) {
+	host := &cliutil.HostResources{CPUs: 8, MemoryMiB: 4095.5}
+
+	cases := []struct {
+		scalars  []objects.Scalar
+		jobs     int
+		host     *cliutil.HostResources
+		expected int
+	}{
+		{nil, 4, host, 4},
+		{nil, 0, host, 1},
+		{[]objects.Scalar{{Name: "cpus", Value: 2}}, 16, host, 4},
+		{[]objects.Scalar{{Name: "cpus", Value: 1}, {Name: "mem", Value: 1024, InputMultiplier: 4}}, 16, host, 3},
+		{[]objects.Scalar{{Name: "cpus", Value: 0.5}, {Name: "disk", Value: 100000}}, 4, host, 4},
+		{[]objects.Scalar{{Name: "cpus", Value: 16}}, 4, host, 1},
+		{[]objects.Scalar{{Name: "cpus", Value: 2}}, 16, nil, 16},
+	}
+
+	for _, c := range cases {
+		seed := objects.Seed{}
+		seed.Job.Resources.Scalar = c.scalars
+		if result := batchWorkers(&seed, c.jobs, c.host); result != c.expected {
+			t.Errorf("batchWorkers(%v, %v, %v) == %v, expected %v", c.scalars, c.jobs, c.host, result, c.expected)
+		}
+	}
+}
+
+func TestTruncateInputs(t *testing.T) {
+	sep := string(filepath.Separator)
+	cases := []struct {
+		inputs   []string
+		expected []string
+	}{
+		{[]string{"INPUT_FILE=" + filepath.FromSlash("../testdata/seed-scale.zip")},
+			[]string{"INPUT_FILE=..." + sep + "seed-scale.zip"}},
+		{[]string{"INPUT_FILE=inputs.txt", "MULTIPLE=" + filepath.FromSlash("/data/inputs/")},
+			[]string{"INPUT_FILE=inputs.txt", "MULTIPLE=..." + sep + "inputs"}},
+		{[]string{"INPUT_FILE=", "inputs.txt"}, []string{"INPUT_FILE=", "inputs.txt"}},
+	}
+
+	for _, c := range cases {
+		result := truncateInputs(c.inputs)
+		if fmt.Sprintf("%q", result) != fmt.Sprintf("%q", c.expected) {
+			t.Errorf("truncateInputs(%q) == %q, expected %q", c.inputs, result, c.expected)
+		}
+	}
+}
+
+func TestProcessBatchFileColumns(t *testing.T) {
+	seed := objects.Seed{}
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "IMAGE", Required: true}, {Name: "MASKS", Multiple: true}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "BANDS", Type: "array"}}
+	seed.Job.Interface.Settings = []objects.Setting{{Name: "THRESHOLD"}}
+	seed.Job.Interface.Mounts = []objects.Mount{{Name: "MODELS", Path: "/models"}}
+
+	dir, _ := ioutil.TempDir("", "seed-batch-")
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		contents         string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{"IMAGE, MASKS\n/data/a.tif, /data/a-mask.tif\nb.tif,\n",
+			"[{[IMAGE=/data/a.tif MASKS=/data/a-mask.tif] [] out/1-a.tif-a-mask.tif [] []} {[IMAGE=b.tif] [] out/2-b.tif [] []}]", ""},
+		{"\xef\xbb\xbfinput:image,json:bands,setting:threshold,mount:MODELS\r\n\"/data/my, file.tif\",\"[1, 2]\",0.5,/models\r\n,,,\r\n",
+			"[{[IMAGE=/data/my, file.tif] [BANDS=[1, 2]] out/1-my, file.tif [THRESHOLD=0.5] [MODELS=/models]}]", ""},
+		{"IMAGE,MASKS,MASKS\na.tif,m1.tif,m2.tif\n", "[{[IMAGE=a.tif MASKS=m1.tif MASKS=m2.tif] [] out/1-a.tif-m1.tif-m2.tif [] []}]", ""},
+		{"IMAGE,IMAGE\na.tif,b.tif\n", "[]", "column 2: IMAGE is given by more than one column"},
+		{"MASKS,setting:UNKNOWN,output:X\na.tif,b,c\n", "[]",
+			"column 2: setting:UNKNOWN is not a setting of the seed manifest\n  column 3: unknown kind output"},
+		{"MASKS\na.tif\n", "[]", "missing required key IMAGE"},
+		{"IMAGE\n\"a.tif\n", "[]", "ERROR: Invalid batch file"},
+		{"IMAGE,MASKS\na.tif\n", "[]", "wrong number of fields"},
+	}
+
+	for _, c := range cases {
+		batchFile := filepath.Join(dir, "batch.csv")
+		ioutil.WriteFile(batchFile, []byte(c.contents), 0644)
+		out, err := ProcessBatchFile(seed, batchFile, "out")
+		outstr := strings.Replace(fmt.Sprintf("%v", out), string(filepath.Separator), "/", -1)
+		if outstr != c.expected {
+			t.Errorf("ProcessBatchFile(%q) == %v, expected %v", c.contents, outstr, c.expected)
+		}
+		if (err != nil) != (c.expectedErrorMsg != "") || err != nil && !strings.Contains(err.Error(), c.expectedErrorMsg) {
+			t.Errorf("ProcessBatchFile(%q) == %v, expected %v", c.contents, err, c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	seed := objects.Seed{}
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "IMAGE", Required: true}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "BANDS", Type: "array", Required: true}}
+	seed.Job.Interface.Settings = []objects.Setting{{Name: "THRESHOLD"}}
+	seed.Job.Interface.Mounts = []objects.Mount{{Name: "MODELS", Path: "/models"}}
+
+	complete := BatchIO{Inputs: []string{"IMAGE=a.tif"}, Json: []string{"BANDS=[1]"}, Outdir: "out/1-a.tif",
+		Settings: []string{"threshold=0.5"}, Mounts: []string{"MODELS=/models"}}
+	partial := BatchIO{Json: []string{"BANDS=2"}, Outdir: "out/2"}
+	uri := BatchIO{Inputs: []string{"IMAGE=b.tif"}, Json: []string{"BANDS=@s3://bucket/bands.json"}, Outdir: "out/3-b.tif"}
+
+	cases := []struct {
+		rows     []BatchIO
+		settings []string
+		mounts   []string
+		expected string
+	}{
+		{[]BatchIO{complete}, nil, nil, ""},
+		{[]BatchIO{complete, uri}, []string{"THRESHOLD=1"}, []string{"MODELS=/opt/models"}, ""},
+		{[]BatchIO{uri}, nil, []string{"MODELS=/opt/models"}, "3-b.tif: missing value for setting THRESHOLD\n"},
+		{[]BatchIO{partial}, []string{"THRESHOLD=1"}, []string{"MODELS=/opt/models"},
+			"2: missing value for required input IMAGE\n  2: BANDS: expected an array, got an integer: 2\n"},
+	}
+
+	for _, c := range cases {
+		err := ValidateBatch(&seed, c.rows, c.settings, c.mounts)
+		if c.expected == "" && err != nil || c.expected != "" && (err == nil || !strings.HasSuffix(err.Error(), c.expected)) {
+			t.Errorf("ValidateBatch(%v, %v, %v) == %v, expected %v", c.rows, c.settings, c.mounts, err, c.expected)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// retryBackoff is the delay before the first retry of a batch row. The delay doubles with each
+// retry up to maxRetryBackoff
+var retryBackoff = 5 * time.Second
+
+const maxRetryBackoff = 5 * time.Minute
+
+//BatchState records the result of each row of a batch as it finishes so the batch can be
+// resumed. The state is a file of one json BatchRowResult per line that is only ever appended
+// to, so a batch that dies leaves at most its last line incomplete
+type BatchState struct {
+	File     string
+	file     *os.File
+	finished map[string]BatchRowResult
+	// latest is the last result recorded for each row directory, whatever its settings and mounts
+	latest map[string]BatchRowResult
+}
+
+// batchStateRow is a line of the state file: the result of a row and a digest of the settings
+// and mounts it was run with. The digest is recorded instead of the values of the settings, which
+// may be secret
+type batchStateRow struct {
+	BatchRowResult
+	Config string `json:"config,omitempty"`
+}
+
+//BatchStateFile returns the path of the state file of the batch writing to outdir
+func BatchStateFile(outdir string) string {
+	return batchFile(outdir, ".state.jsonl")
+}
+
+// batchFile returns the path of a file of the batch writing to outdir with the given suffix. It
+// is next to the output directory, or in the seed cache for an output directory given as a URI
+func batchFile(outdir, suffix string) string {
+	if remote.IsURI(outdir) {
+		sum := sha256.Sum256([]byte(outdir))
+		return filepath.Join(stager.Dir, "batches", hex.EncodeToString(sum[:8])+suffix)
+	}
+	return filepath.Clean(outdir) + suffix
+}
+
+//OpenBatchState opens the state file of the batch writing to outdir. If resume is true the
+// rows recorded by previous runs of the batch are read, otherwise the file is started over
+func OpenBatchState(outdir string, resume bool) (*BatchState, error) {
+	state := &BatchState{File: BatchStateFile(outdir), finished: map[string]BatchRowResult{},
+		latest: map[string]BatchRowResult{}}
+	if err := os.MkdirAll(filepath.Dir(state.File), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to create batch state file %s: %s\n", state.File, err.Error())
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if err := state.read(); os.IsNotExist(err) {
+			util.PrintUtil("WARNING: No batch state found at %s; running every row.\n", state.File)
+		} else if err != nil {
+			return nil, fmt.Errorf("ERROR: Unable to read batch state file %s: %s\n", state.File, err.Error())
+		}
+	}
+
+	var err error
+	if state.file, err = os.OpenFile(state.File, flags, 0644); err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to open batch state file %s: %s\n", state.File, err.Error())
+	}
+	return state, nil
+}
+
+// read loads the rows recorded in the state file. The last record of a row wins
+func (s *BatchState) read() error {
+	f, err := os.Open(s.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var row batchStateRow
+			if jsonErr := json.Unmarshal(line, &row); jsonErr != nil {
+				util.PrintUtil("WARNING: Ignoring incomplete line of batch state file %s\n", s.File)
+			} else {
+				// rows recorded by earlier versions of seed only have their output directory
+				if row.RowDir == "" {
+					row.RowDir = row.OutputDir
+				}
+				s.finished[batchRowKey(row.RowDir, row.Inputs, row.Json, row.Config)] = row.BatchRowResult
+				s.latest[row.RowDir] = row.BatchRowResult
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+//Succeeded returns the recorded result of a row that succeeded in a previous run of the batch
+// with the same settings and mounts. Nothing has succeeded for a nil state, such as that of a
+// dry run
+func (s *BatchState) Succeeded(in BatchIO, settings, mounts []string) (BatchRowResult, bool) {
+	if s == nil {
+		return BatchRowResult{}, false
+	}
+	row, ok := s.finished[batchRowKey(in.Outdir, in.Inputs, in.Json, batchRowConfig(settings, mounts))]
+	return row, ok && row.Status == RunStatusSuccess
+}
+
+//Record appends the result of a row run with the given settings and mounts to the state file
+func (s *BatchState) Record(row BatchRowResult, settings, mounts []string) error {
+	line, err := json.Marshal(batchStateRow{BatchRowResult: row, Config: batchRowConfig(settings, mounts)})
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+//Close closes the state file
+func (s *BatchState) Close() error {
+	return s.file.Close()
+}
+
+// batchRowKey identifies a row by its output directory, its inputs and the digest of its settings
+// and mounts, so the row isn't
+// skipped if the batch file was changed since it ran
+func batchRowKey(outdir string, inputs, json []string, config string) string {
+	return strings.Join(append(append([]string{outdir, config}, inputs...), json...), "\x00")
+}
+
+// batchRowConfig returns a digest of the settings and mounts of a row, or an empty string for a
+// row without any, as recorded by earlier versions of seed
+func batchRowConfig(settings, mounts []string) string {
+	if len(settings) == 0 && len(mounts) == 0 {
+		return ""
+	}
+	settings = append([]string{}, settings...)
+	mounts = append([]string{}, mounts...)
+	sort.Strings(settings)
+	sort.Strings(mounts)
+	sum := sha256.Sum256([]byte(strings.Join(settings, "\x00") + "\x01" + strings.Join(mounts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// retryable returns true if a row that failed with the given error and exit code may succeed
+// if run again: only the errors of the seed manifest in the job category (the default) are
+// retried. Errors in the data category, timeouts and undeclared exit codes are not
+func retryable(seed *objects.Seed, err error, exitCode int) bool {
+	if err == nil || err == ErrJobTimeout || err == ErrCancelled {
+		return false
+	}
+	for _, e := range seed.Job.Errors {
+		if e.Code == exitCode {
+			return e.Category == "" || e.Category == constants.JobErrorCategory
+		}
+	}
+	return false
+}
+
+// retryDelay returns the delay before the given retry of a row, starting at 1
+func retryDelay(retry int) time.Duration {
+	delay := retryBackoff
+	for i := 1; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
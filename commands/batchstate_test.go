@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestBatchState(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-batch-state-")
+	defer os.RemoveAll(dir)
+	outdir := filepath.Join(dir, "out") + string(filepath.Separator)
+	if file := BatchStateFile(outdir); file != filepath.Join(dir, "out.state.jsonl") {
+		t.Errorf("BatchStateFile(%q) == %v, expected %v", outdir, file, filepath.Join(dir, "out.state.jsonl"))
+	}
+
+	rows := []BatchIO{
+		{Inputs: []string{"IMAGE=a.tif"}, Outdir: filepath.Join(outdir, "1-a.tif")},
+		{Inputs: []string{"IMAGE=b.tif"}, Outdir: filepath.Join(outdir, "2-b.tif")},
+		{Inputs: []string{"IMAGE=c.tif"}, Outdir: filepath.Join(outdir, "3-c.tif")},
+		{Inputs: []string{"IMAGE=d.tif"}, Outdir: filepath.Join(outdir, "4-d.tif")},
+	}
+	record := func(state *BatchState, in BatchIO, status string) {
+		state.Record(BatchRowResult{Inputs: in.Inputs, RowDir: in.Outdir, OutputDir: in.Outdir, Status: status}, nil, nil)
+	}
+	settings, mounts := []string{"MODE=fast", "PASSWORD=hunter2"}, []string{"DATA=/data"}
+	// a retried row writes to a time-stamped sub-directory of the row output directory
+	retried := filepath.Join(rows[0].Outdir, "20180101_000000")
+
+	state, err := OpenBatchState(outdir, true)
+	if err != nil {
+		t.Fatalf("OpenBatchState(%q, true) == %v, expected no error", outdir, err)
+	}
+	record(state, rows[0], RunStatusFailed)
+	state.Record(BatchRowResult{Inputs: rows[0].Inputs, RowDir: rows[0].Outdir, OutputDir: retried, Status: RunStatusSuccess}, nil, nil)
+	record(state, rows[1], RunStatusSuccess)
+	// rows recorded before the row directory was recorded are identified by their output directory
+	state.Record(BatchRowResult{Inputs: rows[2].Inputs, OutputDir: rows[2].Outdir, Status: RunStatusSuccess}, nil, nil)
+	record(state, rows[1], RunStatusFailed)
+	state.Record(BatchRowResult{Inputs: rows[3].Inputs, RowDir: rows[3].Outdir, OutputDir: rows[3].Outdir,
+		Status: RunStatusSuccess}, settings, mounts)
+	state.Close()
+	if data, _ := ioutil.ReadFile(state.File); strings.Contains(string(data), "hunter2") {
+		t.Errorf("Record wrote the value of a setting to %s: %q", state.File, data)
+	}
+	// a batch that dies while recording a row leaves an incomplete line
+	f, _ := os.OpenFile(state.File, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"inputs": ["IMAGE=c.tif"], "outputDir": `)
+	f.Close()
+
+	changed := BatchIO{Inputs: []string{"IMAGE=other.tif"}, Outdir: rows[2].Outdir}
+	cases := []struct {
+		resume   bool
+		row      BatchIO
+		settings []string
+		mounts   []string
+		expected bool
+	}{
+		{true, rows[0], nil, nil, true},
+		{true, rows[1], nil, nil, false},
+		{true, rows[2], nil, nil, true},
+		{true, changed, nil, nil, false},
+		{true, rows[3], []string{"PASSWORD=hunter2", "MODE=fast"}, mounts, true},
+		{true, rows[3], []string{"MODE=slow", "PASSWORD=hunter2"}, mounts, false},
+		{true, rows[3], settings, []string{"DATA=/other"}, false},
+		{true, rows[3], nil, nil, false},
+		{true, rows[0], settings, nil, false},
+		{false, rows[0], nil, nil, false},
+	}
+
+	for _, c := range cases {
+		state, err := OpenBatchState(outdir, c.resume)
+		if err != nil {
+			t.Errorf("OpenBatchState(%q, %v) == %v, expected no error", outdir, c.resume, err)
+			continue
+		}
+		row, result := state.Succeeded(c.row, c.settings, c.mounts)
+		if result != c.expected {
+			t.Errorf("Succeeded(%v, %q, %q) with resume %v == %v, expected %v", c.row, c.settings, c.mounts, c.resume,
+				result, c.expected)
+		}
+		if result && c.row.Outdir == rows[0].Outdir && row.OutputDir != retried {
+			t.Errorf("Succeeded(%v) recorded output directory %v, expected %v", c.row, row.OutputDir, retried)
+		}
+		state.Close()
+	}
+
+	if data, _ := ioutil.ReadFile(state.File); len(data) != 0 {
+		t.Errorf("OpenBatchState(%q, false) kept %q, expected an empty state file", outdir, data)
+	}
+}
+
+func TestRowOutputDir(t *testing.T) {
+	in := BatchIO{Outdir: "/out/1-a.tif"}
+	cases := []struct {
+		report   RunReport
+		expected string
+	}{
+		{RunReport{}, "/out/1-a.tif"},
+		{RunReport{OutputDir: "/out/1-a.tif"}, "/out/1-a.tif"},
+		{RunReport{OutputDir: "/out/1-a.tif/20180101_000000"}, "/out/1-a.tif/20180101_000000"},
+		{RunReport{OutputDir: "/cache/1-a.tif", UploadedTo: "s3://bucket/out/1-a.tif"}, "s3://bucket/out/1-a.tif"},
+	}
+
+	for _, c := range cases {
+		if result := rowOutputDir(in, &c.report); result != c.expected {
+			t.Errorf("rowOutputDir(%v, %v) == %v, expected %v", in, c.report, result, c.expected)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	seed := objects.Seed{}
+	seed.Job.Errors = []objects.ErrorMap{
+		{Code: 1, Name: "bad-input", Category: "data"},
+		{Code: 2, Name: "out-of-memory", Category: "job"},
+		{Code: 3, Name: "unavailable"},
+	}
+
+	cases := []struct {
+		err      error
+		exitCode int
+		expected bool
+	}{
+		{nil, 0, false},
+		{&cliutil.ExitError{Code: 1}, 1, false},
+		{&cliutil.ExitError{Code: 2}, 2, true},
+		{&cliutil.ExitError{Code: 3}, 3, true},
+		{&cliutil.ExitError{Code: 4}, 4, false},
+		{ErrJobTimeout, 124, false},
+		{ErrCancelled, 130, false},
+		{errors.New("ERROR: Unable to find image"), 0, false},
+	}
+
+	for _, c := range cases {
+		if result := retryable(&seed, c.err, c.exitCode); result != c.expected {
+			t.Errorf("retryable(%v, %v) == %v, expected %v", c.err, c.exitCode, result, c.expected)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		retry    int
+		expected time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{7, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+
+	for _, c := range cases {
+		if result := retryDelay(c.retry); result != c.expected {
+			t.Errorf("retryDelay(%v) == %v, expected %v", c.retry, result, c.expected)
+		}
+	}
+}
@@ -1,14 +1,8 @@
 package commands
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
-	"strings"
-	"time"
 
 	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/constants"
@@ -20,19 +14,14 @@ import (
 //DockerBuild Builds the docker image with the given image tag.
 func DockerBuild(jobDirectory, version, username, password, manifest, dockerfile, cacheFrom string, warnAsError bool) (string, error) {
 	if username != "" {
-		//set config dir so we don't stomp on other users' logins with sudo
-		configDir := common_const.DockerConfigDir + time.Now().Format(time.RFC3339)
-		os.Setenv(common_const.DockerConfigKey, configDir)
-		defer util.RemoveAllFiles(configDir)
-		defer os.Unsetenv(common_const.DockerConfigKey)
-
 		registry, err := util.DockerfileBaseRegistry(jobDirectory)
 		if err != nil {
 			util.PrintUtil("Error getting registry from dockerfile: %s\n", err.Error())
 		}
-		err = util.Login(registry, username, password)
+		logout, err := cliutil.Runtime().Login(registry, username, password)
+		defer logout()
 		if err != nil {
-			util.PrintUtil("Error calling docker login: %s\n", err.Error())
+			util.PrintUtil("Error calling %s login: %s\n", cliutil.Runtime().Name(), err.Error())
 		}
 	}
 
@@ -68,16 +57,7 @@ func DockerBuild(jobDirectory, version, username, password, manifest, dockerfile
 
 	// Build Docker image
 	util.PrintUtil("INFO: Building %s\n", imageName)
-	var buildArgs, dockerCommand = cliutil.DockerCommandArgsInit()
-	buildArgs = append(buildArgs, "build")
-	// docker doesn't care about validating the cache-from image
-	if cacheFrom != "" {
-		buildArgs = append(buildArgs, "--cache-from")
-		buildArgs = append(buildArgs, cacheFrom)
-	}
-
-	buildArgs = append(buildArgs, "-t")
-	buildArgs = append(buildArgs, imageName)
+	opts := cliutil.BuildOptions{ContextDir: util.GetFullPath(jobDirectory, ""), Tag: imageName, CacheFrom: cacheFrom}
 
 	util.PrintUtil("dockerfile: %s\n", dockerfile)
 	if dockerfile != "." {
@@ -86,42 +66,19 @@ func DockerBuild(jobDirectory, version, username, password, manifest, dockerfile
 			util.PrintUtil("ERROR: Dockerfile not found. %s\n", err.Error())
 			return imageName, err
 		}
-		buildArgs = append(buildArgs, "-f")
-		buildArgs = append(buildArgs, dfile)
+		opts.Dockerfile = dfile
 	}
 
-	buildArgs = append(buildArgs, util.GetFullPath(jobDirectory, ""))
-
-	if util.DockerVersionHasLabel() {
+	if cliutil.Runtime().SupportsLabels() {
 		// Set the seed.manifest.json contents as an image label
-		label := "com.ngageoint.seed.manifest=" + objects.GetManifestLabel(seedFileName)
-		buildArgs = append(buildArgs, "--label", label)
-	}
-
-	util.PrintUtil("INFO: Running Docker command:\n%s %s\n", dockerCommand, strings.Join(buildArgs, " "))
-
-	cmd := exec.Command(dockerCommand, buildArgs...)
-	var errs bytes.Buffer
-	if util.StdErr != nil {
-		cmd.Stderr = io.MultiWriter(util.StdErr, &errs)
-	} else {
-		cmd.Stderr = &errs
-	}
-	cmd.Stdout = util.StdErr
-
-	// Run docker build
-	if err := cmd.Run(); err != nil {
-		util.PrintUtil("ERROR: Error executing docker build. %s\n",
-			err.Error())
-		return imageName, err
+		opts.Labels = map[string]string{cliutil.ManifestLabel: objects.GetManifestLabel(seedFileName)}
 	}
 
-	// check for errors on stderr
-	if errs.String() != "" {
-		util.PrintUtil("ERROR: Error building image '%s':\n%s\n",
-			imageName, errs.String())
+	// Run docker build. Build output is written to stderr so only the result decides failure
+	if err = cliutil.Runtime().Build(opts); err != nil {
+		util.PrintUtil("ERROR: Error building image '%s':\n%s\n", imageName, err.Error())
 		util.PrintUtil("Exiting seed...\n")
-		return imageName, errors.New(errs.String())
+		return imageName, err
 	}
 
 	inputStr := ""
@@ -185,6 +142,8 @@ func PrintBuildUsage() {
 		constants.ShortPassFlag, constants.PassFlag)
 	util.PrintUtil("  -%s -%s\t  Specifies whether to treat warnings as errors during validation\n",
 		constants.ShortWarnAsErrorsFlag, constants.WarnAsErrorsFlag)
+	util.PrintUtil("  -%s\t  Container runtime to use: docker or podman (default is $%s or docker).\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
 
 	util.PrintUtil("\nBuild and Publish options:\n")
 	util.PrintUtil("  -%s\t  Will publish image after a successful build.\n",
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"text/tabwriter"
+
+	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+//CapacityCheck compares the amount of a resource a job requires with what the host has available
+type CapacityCheck struct {
+	Resource  string
+	Requested float64
+	Available float64
+	Unit      string
+}
+
+//Sufficient returns true if the host has enough of the resource for the job
+func (c CapacityCheck) Sufficient() bool {
+	return c.Requested <= c.Available
+}
+
+//CheckHostCapacity compares the cpus, mem, sharedMem and disk required by the job with the
+// capacity of the container host and the free space of the filesystem holding the output
+// directory. Resources that aren't required or whose availability can't be determined
+// are not checked
+func CheckHostCapacity(seed *objects.Seed, inputSizeMiB float64, outDir string) []CapacityCheck {
+	var host *cliutil.HostResources
+	required := false
+	for _, s := range seed.Job.Resources.Scalar {
+		if s.Name == "cpus" || s.Name == "mem" || s.Name == "sharedMem" {
+			required = true
+		}
+	}
+	if required {
+		var err error
+		if host, err = cliutil.Runtime().HostResources(); err != nil {
+			util.PrintUtil("WARNING: Unable to determine the capacity of the %s host; cpus and memory are not checked.\n%s\n",
+				cliutil.Runtime().Name(), err.Error())
+		}
+	}
+
+	var freeDisk float64
+	var diskErr error
+	if outDir != "" {
+		var free uint64
+		free, diskErr = DiskFree(outDir)
+		freeDisk = float64(free) / (1024 * 1024)
+	}
+
+	return hostCapacity(seed, inputSizeMiB, host, freeDisk, diskErr)
+}
+
+// hostCapacity evaluates the scalar resources of the seed against the given host capacity
+// and free disk space in MiB. host is nil and diskErr not nil when they aren't known
+func hostCapacity(seed *objects.Seed, inputSizeMiB float64, host *cliutil.HostResources, freeDisk float64, diskErr error) []CapacityCheck {
+	var checks []CapacityCheck
+	for _, s := range seed.Job.Resources.Scalar {
+		amount := scalarAmount(s, inputSizeMiB)
+		switch s.Name {
+		case "cpus":
+			if host != nil {
+				checks = append(checks, CapacityCheck{s.Name, amount, float64(host.CPUs), "cpus"})
+			}
+		case "mem", "sharedMem":
+			if host != nil {
+				checks = append(checks, CapacityCheck{s.Name, math.Ceil(amount), math.Floor(host.MemoryMiB), "MiB"})
+			}
+		case "disk":
+			if diskErr != nil {
+				util.PrintUtil("WARNING: Unable to determine the free space of the output directory; disk is not checked.\n%s\n",
+					diskErr.Error())
+				continue
+			}
+			checks = append(checks, CapacityCheck{s.Name, math.Ceil(amount), math.Floor(freeDisk), "MiB"})
+		}
+	}
+	return checks
+}
+
+//CapacityError returns an error with a breakdown of the requested and available resources
+// if any of the checks is insufficient, or nil if the host has enough of every resource
+func CapacityError(checks []CapacityCheck) error {
+	insufficient := false
+	for _, c := range checks {
+		if !c.Sufficient() {
+			insufficient = true
+		}
+	}
+	if !insufficient {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("ERROR: The host does not have enough resources to run the job:\n")
+	w := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  RESOURCE\tREQUESTED\tAVAILABLE\t")
+	for _, c := range checks {
+		status := ""
+		if !c.Sufficient() {
+			status = "insufficient"
+		}
+		fmt.Fprintf(w, "  %s\t%s %s\t%s %s\t%s\n", c.Resource, formatAmount(c.Requested), c.Unit,
+			formatAmount(c.Available), c.Unit, status)
+	}
+	w.Flush()
+	return errors.New(buffer.String())
+}
+
+// formatAmount prints whole amounts without decimals
+func formatAmount(amount float64) string {
+	if amount == math.Trunc(amount) {
+		return fmt.Sprintf("%.0f", amount)
+	}
+	return fmt.Sprintf("%.2f", amount)
+}
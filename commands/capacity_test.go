@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestHostCapacity(t *testing.T) {
+	seed := objects.Seed{}
+	seed.Job.Resources.Scalar = []objects.Scalar{
+		{Name: "cpus", Value: 2},
+		{Name: "mem", Value: 512, InputMultiplier: 2},
+		{Name: "sharedMem", Value: 64},
+		{Name: "disk", Value: 10.5},
+		{Name: "gpus", Value: 1},
+	}
+	host := &cliutil.HostResources{CPUs: 4, MemoryMiB: 2047.5}
+
+	cases := []struct {
+		inputSize float64
+		host      *cliutil.HostResources
+		freeDisk  float64
+		diskErr   error
+		expected  string
+	}{
+		{0, host, 1024, nil, "[{cpus 2 4 cpus} {mem 512 2047 MiB} {sharedMem 64 2047 MiB} {disk 11 1024 MiB}]"},
+		{1000, host, 1024, nil, "[{cpus 2 4 cpus} {mem 2512 2047 MiB} {sharedMem 64 2047 MiB} {disk 11 1024 MiB}]"},
+		{0, nil, 5, nil, "[{disk 11 5 MiB}]"},
+		{0, host, 0, errors.New("no such directory"), "[{cpus 2 4 cpus} {mem 512 2047 MiB} {sharedMem 64 2047 MiB}]"},
+	}
+
+	for _, c := range cases {
+		result := fmt.Sprintf("%v", hostCapacity(&seed, c.inputSize, c.host, c.freeDisk, c.diskErr))
+		if result != c.expected {
+			t.Errorf("hostCapacity(%v, %v, %v, %v) == %v, expected %v", c.inputSize, c.host, c.freeDisk, c.diskErr, result, c.expected)
+		}
+	}
+}
+
+func TestCapacityError(t *testing.T) {
+	cases := []struct {
+		checks   []CapacityCheck
+		expected []string
+	}{
+		{nil, nil},
+		{[]CapacityCheck{{"cpus", 2, 4, "cpus"}, {"disk", 1024, 1024, "MiB"}}, nil},
+		{[]CapacityCheck{{"cpus", 0.5, 4, "cpus"}, {"mem", 8192, 2047, "MiB"}},
+			[]string{"ERROR: ", "cpus", "0.50 cpus", "8192 MiB", "2047 MiB", "insufficient"}},
+	}
+
+	for _, c := range cases {
+		err := CapacityError(c.checks)
+		if (err == nil) != (c.expected == nil) {
+			t.Errorf("CapacityError(%v) == %v, expected an error %v", c.checks, err, c.expected != nil)
+			continue
+		}
+		for _, e := range c.expected {
+			if !strings.Contains(err.Error(), e) {
+				t.Errorf("CapacityError(%v) == %q, expected it to contain %q", c.checks, err.Error(), e)
+			}
+		}
+	}
+}
+
+func TestDiskFree(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-disk-")
+	defer os.RemoveAll(dir)
+
+	if free, err := DiskFree(dir); err != nil || free == 0 {
+		t.Errorf("DiskFree(%q) == %v, %v, expected free space", dir, free, err)
+	}
+	if _, err := DiskFree(dir + "/missing"); err == nil {
+		t.Errorf("DiskFree(%q) returned no error, expected an error", dir+"/missing")
+	}
+}
@@ -0,0 +1,345 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+//OutputColumn describes a column of an OutputTable. Source is row for the columns describing
+// the batch row, or input, json or output for the columns of the inputs, json inputs and json
+// outputs declared by the seed manifest. Type is the declared type of the column
+type OutputColumn struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Source string `json:"source"`
+}
+
+//OutputTable holds the json outputs of the rows of a batch with the inputs of each row. Each
+// row maps the column names to their values, which are nil if they weren't given or found
+type OutputTable struct {
+	Image   string                   `json:"image"`
+	Columns []OutputColumn           `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+//CollectOutputs reads the seed.outputs.json file of each row of a batch and extracts the json
+// outputs declared by the seed manifest into a table with a column for the row number, output
+// directory and status of the row, one for each input and json input and one for each json
+// output. Values that don't match the type declared by the manifest are left out and reported
+// in the returned warnings
+func CollectOutputs(seed *objects.Seed, imageName string, rows []BatchRowResult) (*OutputTable, []string) {
+	table := &OutputTable{Image: imageName, Rows: []map[string]interface{}{}, Columns: []OutputColumn{
+		{"row", "integer", "row"}, {"outputDir", "string", "row"}, {"status", "string", "row"},
+	}}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		table.Columns = append(table.Columns, OutputColumn{f.Name, "string", batchInputColumn})
+	}
+	for _, j := range seed.Job.Interface.Inputs.Json {
+		table.Columns = append(table.Columns, OutputColumn{j.Name, j.Type, batchJsonColumn})
+	}
+	for _, o := range seed.Job.Interface.Outputs.JSON {
+		table.Columns = append(table.Columns, OutputColumn{o.Name, o.Type, "output"})
+	}
+
+	var warnings []string
+	for i, row := range rows {
+		label := batchRowName(row)
+		values := map[string]interface{}{"row": json.Number(strconv.Itoa(i + 1)), "outputDir": row.OutputDir, "status": nil}
+		if row.Status != "" {
+			values["status"] = row.Status
+		}
+
+		inputs := inputValues(row.Inputs, false)
+		for _, f := range seed.Job.Interface.Inputs.Files {
+			values[f.Name] = nil
+			if len(inputs[f.Name]) > 0 {
+				values[f.Name] = strings.Join(inputs[f.Name], " ")
+			}
+		}
+		jsonValues := inputMap(row.Json, false)
+		for _, j := range seed.Job.Interface.Inputs.Json {
+			values[j.Name] = nil
+			if value, ok := jsonValues[j.Name]; ok {
+				typed, err := typedJsonInput(j, value)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: %s", label, err.Error()))
+				}
+				values[j.Name] = typed
+			}
+		}
+
+		outputs, err := readRowOutputs(row.OutputDir)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", label, err.Error()))
+		}
+		for _, o := range seed.Job.Interface.Outputs.JSON {
+			key := o.Name
+			if o.Key != "" {
+				key = o.Key
+			}
+			value, ok := outputs[key]
+			values[o.Name] = nil
+			if !ok {
+				continue
+			}
+			if actual := JsonType(value); !jsonTypeMatches(o.Type, actual) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s: expected %s, got %s", label, o.Name, article(o.Type), article(actual)))
+				continue
+			}
+			values[o.Name] = value
+		}
+		table.Rows = append(table.Rows, values)
+	}
+	return table, warnings
+}
+
+// typedJsonInput decodes the value of a json input given to a row as described by jsonInputValue
+func typedJsonInput(in objects.InJson, value string) (interface{}, error) {
+	prefix := constants.JsonFilePrefix
+	if strings.HasPrefix(value, prefix) && remote.IsURI(value[len(prefix):]) {
+		return value, nil
+	}
+	value, err := jsonInputValue(in, value)
+	if err != nil || in.Type == "string" || !json.Valid([]byte(value)) {
+		return value, err
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	decoder.Decode(&decoded)
+	return decoded, nil
+}
+
+// readRowOutputs reads the seed.outputs.json file written by a row to the output directory
+// recorded for it, which for a retried row is the directory of its last attempt. Returns nil if
+// the row has no outputs file
+func readRowOutputs(outDir string) (map[string]interface{}, error) {
+	if remote.IsURI(outDir) {
+		return nil, fmt.Errorf("outputs uploaded to %s are not collected", outDir)
+	}
+
+	fileName := filepath.Join(outDir, constants.ResultsFileManifestName)
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var outputs map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&outputs); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", fileName, err.Error())
+	}
+	return outputs, nil
+}
+
+//ReadBatchRows returns the rows of the batch that wrote to outdir from its batch state file,
+// ordered by row number. If the batch has no state file, there is a row for each sub-directory
+// of the output directory, without their inputs
+func ReadBatchRows(outdir string) ([]BatchRowResult, error) {
+	state := &BatchState{File: BatchStateFile(outdir), finished: map[string]BatchRowResult{},
+		latest: map[string]BatchRowResult{}}
+	if err := state.read(); err == nil {
+		var rows []BatchRowResult
+		for _, row := range state.latest {
+			rows = append(rows, row)
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			return batchRowLess(rows[i].RowDir, rows[j].RowDir)
+		})
+		return rows, nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("ERROR: Unable to read batch state file %s: %s\n", state.File, err.Error())
+	}
+
+	if remote.IsURI(outdir) {
+		return nil, fmt.Errorf("ERROR: No batch state found for %s\n", outdir)
+	}
+	files, err := ioutil.ReadDir(outdir)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to read batch output directory %s: %s\n", outdir, err.Error())
+	}
+	var rows []BatchRowResult
+	for _, f := range files {
+		if f.IsDir() {
+			rowDir := filepath.Join(outdir, f.Name())
+			rows = append(rows, BatchRowResult{RowDir: rowDir, OutputDir: rowDir})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return batchRowLess(rows[i].RowDir, rows[j].RowDir)
+	})
+	return rows, nil
+}
+
+// batchRowLess orders row directories by the row number they start with, if any, and
+// then by name
+func batchRowLess(a, b string) bool {
+	a, b = filepath.Base(a), filepath.Base(b)
+	na, errA := strconv.Atoi(strings.SplitN(a, "-", 2)[0])
+	nb, errB := strconv.Atoi(strings.SplitN(b, "-", 2)[0])
+	if errA == nil && errB == nil && na != nb {
+		return na < nb
+	} else if (errA == nil) != (errB == nil) {
+		return errA == nil
+	}
+	return a < b
+}
+
+//WriteOutputTable writes the table as CSV, with a header of the column names, or as a json
+// OutputTable document. CSV values that are objects or arrays are written as JSON
+func WriteOutputTable(w io.Writer, format string, table *OutputTable) error {
+	switch format {
+	case constants.JsonFormat:
+		data, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			return fmt.Errorf("ERROR: Error encoding the output table: %s\n", err.Error())
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case constants.CsvFormat:
+		writer := csv.NewWriter(w)
+		var record []string
+		for _, c := range table.Columns {
+			record = append(record, c.Name)
+		}
+		writer.Write(record)
+		for _, row := range table.Rows {
+			record = record[:0]
+			for _, c := range table.Columns {
+				record = append(record, csvValue(row[c.Name]))
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("ERROR: Unknown output table format %s. Supported formats are %s and %s.\n",
+		format, constants.CsvFormat, constants.JsonFormat)
+}
+
+// csvValue formats a value of an OutputTable for a CSV file
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+//OutputTableFormat returns the format of an output table file from its extension: json for
+// .json files and csv otherwise
+func OutputTableFormat(fileName string) string {
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		return constants.JsonFormat
+	}
+	return constants.CsvFormat
+}
+
+//CollectBatchOutputs writes the table of the json outputs of the rows of a batch to fileName,
+// or to stdout if fileName is empty, in the given format (see CollectOutputs)
+func CollectBatchOutputs(seed *objects.Seed, imageName string, rows []BatchRowResult, fileName, format string) error {
+	if len(seed.Job.Interface.Outputs.JSON) == 0 {
+		util.PrintUtil("WARNING: The seed manifest of %s doesn't declare any json outputs to collect.\n", imageName)
+	}
+	table, warnings := CollectOutputs(seed, imageName, rows)
+	for _, w := range warnings {
+		util.PrintUtil("WARNING: %s\n", w)
+	}
+
+	if fileName == "" {
+		return WriteOutputTable(resultWriter, format, table)
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("ERROR: Unable to write output table %s: %s\n", fileName, err.Error())
+	}
+	defer f.Close()
+	return WriteOutputTable(f, format, table)
+}
+
+//Collect writes the table of the json outputs of the rows of the batch that wrote to outdir.
+// The seed manifest is read from the image, or from the manifest file if no image is given
+func Collect(imageName, manifest, outdir, format, fileName string) error {
+	if format == "" {
+		format = OutputTableFormat(fileName)
+	}
+	if outdir == "" {
+		return errors.New("ERROR: The output directory of the batch to collect the outputs of must be given.")
+	}
+	if !remote.IsURI(outdir) {
+		outdir = util.GetFullPath(outdir, "")
+	}
+
+	var seed objects.Seed
+	if imageName != "" {
+		var err error
+		if seed, err = cliutil.SeedFromImageLabel(imageName); err != nil {
+			return err
+		}
+	} else {
+		seedFileName := manifest
+		if manifest == "." || manifest == "" {
+			var err error
+			if seedFileName, err = util.SeedFileName("."); err != nil {
+				return fmt.Errorf("ERROR: Seed manifest not found. %s\n", err.Error())
+			}
+		} else if _, err := os.Stat(seedFileName); err != nil {
+			return fmt.Errorf("ERROR: Seed manifest not found. %s\n", err.Error())
+		}
+		seed = objects.SeedFromManifestFile(seedFileName)
+		imageName = objects.BuildImageName(&seed)
+	}
+
+	rows, err := ReadBatchRows(outdir)
+	if err != nil {
+		return err
+	}
+	return CollectBatchOutputs(&seed, imageName, rows, fileName, format)
+}
+
+//PrintCollectUsage prints the seed collect usage arguments, then exits the program
+func PrintCollectUsage() {
+	util.PrintUtil("\nUsage:\tseed collect -o BATCH_OUTPUT_DIR [-in IMAGE_NAME | -M MANIFEST] [OPTIONS] \n")
+
+	util.PrintUtil("\nCollects the json outputs of the rows of a batch into one table.\n")
+
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s Docker image name the batch ran\n",
+		constants.ShortImgNameFlag, constants.ImgNameFlag)
+	util.PrintUtil("  -%s -%s\t  Manifest file to use if an image name is not specified (default is seed.manifest.json within the current directory).\n",
+		constants.ShortManifestFlag, constants.ManifestFlag)
+	util.PrintUtil("  -%s  -%s \t Output directory of the batch\n",
+		constants.ShortJobOutputDirFlag, constants.JobOutputDirFlag)
+	util.PrintUtil("  -%s \t Format of the table: %s or %s (default is %s for -%s files ending in .json, or %s)\n",
+		constants.FormatFlag, constants.CsvFormat, constants.JsonFormat, constants.JsonFormat, constants.TableFlag, constants.CsvFormat)
+	util.PrintUtil("  -%s \t File to write the table to (default is stdout)\n",
+		constants.TableFlag)
+	util.PrintUtil("  -%s \t Container runtime to use: docker or podman (default is $%s or docker)\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
+	return
+}
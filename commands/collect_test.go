@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestCollectOutputs(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-collect-")
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), os.ModePerm)
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	write("1-a.tif/seed.outputs.json", `{"CELL_COUNT": 12, "dateCollected": "2018-01-01", "extra": true}`)
+	write("2-b.tif/seed.outputs.json", `{"CELL_COUNT": "many"}`)
+	write("3-c.tif/20180101_000000/seed.outputs.json", `{"CELL_COUNT": 7.5}`)
+	write("3-c.tif/seed.outputs.json", `{"CELL_COUNT": 1}`)
+
+	seed := objects.Seed{}
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "IMAGE"}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "BANDS", Type: "array"}}
+	seed.Job.Interface.Outputs.JSON = []objects.OutJson{{Name: "CELL_COUNT", Type: "number"},
+		{Name: "DATE", Key: "dateCollected", Type: "string"}}
+	rows := []BatchRowResult{
+		{Inputs: []string{"IMAGE=a.tif"}, Json: []string{"BANDS=[1,2]"}, RowDir: filepath.Join(dir, "1-a.tif"),
+			OutputDir: filepath.Join(dir, "1-a.tif"), Status: RunStatusSuccess},
+		{Inputs: []string{"IMAGE=b.tif"}, RowDir: filepath.Join(dir, "2-b.tif"), OutputDir: filepath.Join(dir, "2-b.tif"), Status: RunStatusSuccess},
+		// a retried row whose last attempt wrote to a time-stamped sub-directory
+		{Inputs: []string{"IMAGE=c.tif"}, RowDir: filepath.Join(dir, "3-c.tif"), OutputDir: filepath.Join(dir, "3-c.tif", "20180101_000000"),
+			Status: RunStatusSuccess},
+		{Inputs: []string{"IMAGE=d.tif"}, RowDir: filepath.Join(dir, "4-d.tif"), OutputDir: filepath.Join(dir, "4-d.tif"), Status: RunStatusFailed},
+	}
+
+	table, warnings := CollectOutputs(&seed, "my-job-0.1.0-seed:1.0.0", rows)
+	var columns []string
+	for _, c := range table.Columns {
+		columns = append(columns, c.Name+":"+c.Type+":"+c.Source)
+	}
+	expected := "row:integer:row outputDir:string:row status:string:row IMAGE:string:input BANDS:array:json CELL_COUNT:number:output DATE:string:output"
+	if strings.Join(columns, " ") != expected {
+		t.Errorf("CollectOutputs() columns == %v, expected %v", strings.Join(columns, " "), expected)
+	}
+
+	var out bytes.Buffer
+	if err := WriteOutputTable(&out, "csv", table); err != nil {
+		t.Errorf("WriteOutputTable() == %v, expected no error", err)
+	}
+	expected = "row,outputDir,status,IMAGE,BANDS,CELL_COUNT,DATE\n" +
+		"1," + rows[0].OutputDir + ",SUCCESS,a.tif,\"[1,2]\",12,2018-01-01\n" +
+		"2," + rows[1].OutputDir + ",SUCCESS,b.tif,,,\n" +
+		"3," + rows[2].OutputDir + ",SUCCESS,c.tif,,7.5,\n" +
+		"4," + rows[3].OutputDir + ",FAILED,d.tif,,,\n"
+	if out.String() != expected {
+		t.Errorf("WriteOutputTable() == %v, expected %v", out.String(), expected)
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "2-b.tif: CELL_COUNT: expected a number, got a string") {
+		t.Errorf("CollectOutputs() warnings == %v, expected a CELL_COUNT type warning for 2-b.tif", warnings)
+	}
+
+	out.Reset()
+	if err := WriteOutputTable(&out, "json", table); err != nil {
+		t.Errorf("WriteOutputTable() == %v, expected no error", err)
+	}
+	if !strings.Contains(out.String(), `"CELL_COUNT": 12,`) || !strings.Contains(out.String(), `"BANDS": [`) {
+		t.Errorf("WriteOutputTable() == %v, expected typed json values", out.String())
+	}
+
+	if err := WriteOutputTable(&out, "xml", table); err == nil {
+		t.Errorf("WriteOutputTable(xml) == nil, expected an error")
+	}
+}
+
+func TestReadBatchRows(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-collect-")
+	defer os.RemoveAll(dir)
+	outdir := filepath.Join(dir, "out")
+	for _, name := range []string{"10-j.tif", "2-b.tif", "1-a.tif", "other"} {
+		os.MkdirAll(filepath.Join(outdir, name), os.ModePerm)
+	}
+
+	rows, err := ReadBatchRows(outdir)
+	var dirs []string
+	for _, row := range rows {
+		dirs = append(dirs, filepath.Base(row.OutputDir))
+	}
+	if err != nil || strings.Join(dirs, " ") != "1-a.tif 2-b.tif 10-j.tif other" {
+		t.Errorf("ReadBatchRows(%q) == %v, %v, expected %v", outdir, dirs, err, "1-a.tif 2-b.tif 10-j.tif other")
+	}
+
+	state, _ := OpenBatchState(outdir, false)
+	state.Record(BatchRowResult{Inputs: []string{"IMAGE=b.tif"}, RowDir: filepath.Join(outdir, "2-b.tif"),
+		OutputDir: filepath.Join(outdir, "2-b.tif", "20180101_000000"), Status: RunStatusSuccess}, nil, nil)
+	// only the last result of a row run again with other settings is read
+	state.Record(BatchRowResult{Inputs: []string{"IMAGE=a.tif"}, RowDir: filepath.Join(outdir, "1-a.tif"),
+		OutputDir: filepath.Join(outdir, "1-a.tif"), Status: RunStatusSuccess}, []string{"MODE=fast"}, nil)
+	state.Record(BatchRowResult{Inputs: []string{"IMAGE=a.tif"}, RowDir: filepath.Join(outdir, "1-a.tif"),
+		OutputDir: filepath.Join(outdir, "1-a.tif"), Status: RunStatusFailed}, nil, nil)
+	state.Close()
+
+	rows, err = ReadBatchRows(outdir)
+	dirs = nil
+	for _, row := range rows {
+		rel, _ := filepath.Rel(outdir, row.OutputDir)
+		dirs = append(dirs, filepath.ToSlash(rel)+":"+row.Status)
+	}
+	if err != nil || strings.Join(dirs, " ") != "1-a.tif:FAILED 2-b.tif/20180101_000000:SUCCESS" {
+		t.Errorf("ReadBatchRows(%q) == %v, %v, expected %v", outdir, dirs, err, "1-a.tif:FAILED 2-b.tif/20180101_000000:SUCCESS")
+	}
+
+	if _, err = ReadBatchRows(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("ReadBatchRows(missing) == nil, expected an error")
+	}
+}
+
+func TestOutputTableFormat(t *testing.T) {
+	cases := []struct {
+		fileName string
+		expected string
+	}{
+		{"", "csv"},
+		{"outputs.csv", "csv"},
+		{"outputs.JSON", "json"},
+		{"outputs.txt", "csv"},
+	}
+
+	for _, c := range cases {
+		if format := OutputTableFormat(c.fileName); format != c.expected {
+			t.Errorf("OutputTableFormat(%q) == %v, expected %v", c.fileName, format, c.expected)
+		}
+	}
+}
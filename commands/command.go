@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+//CommandVars returns the values the job command may reference: the environment variables
+// of the container (inputs, json inputs, settings, resources and OUTPUT_DIR). Secret settings
+// aren't included. Optional inputs and json inputs that weren't given are empty
+func CommandVars(seed *objects.Seed, env []string) map[string]string {
+	vars := map[string]string{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		vars[util.GetNormalizedVariable(f.Name)] = ""
+	}
+	for _, j := range seed.Job.Interface.Inputs.Json {
+		vars[util.GetNormalizedVariable(j.Name)] = ""
+	}
+	for _, e := range env {
+		if x := strings.SplitN(e, "=", 2); len(x) == 2 {
+			vars[x[0]] = x[1]
+		}
+	}
+	return vars
+}
+
+//CheckCommandSecrets returns an error if the job command references any of the secret
+// settings. Secrets are only passed to the container in its environment, as a value
+// substituted into the command would be visible in the arguments of the container
+func CheckCommandSecrets(command string, secrets map[string]string) error {
+	e := &commandExpander{command: command, vars: map[string]string{}}
+	if _, err := e.expand(); err != nil {
+		return err
+	}
+
+	var referenced []string
+	for _, name := range e.undefined {
+		if _, ok := secrets[util.GetNormalizedVariable(name)]; ok {
+			referenced = append(referenced, name)
+		}
+	}
+	if len(referenced) > 0 {
+		sort.Strings(referenced)
+		return fmt.Errorf("ERROR: The job command references secret settings, which are only passed in the environment of the container: %s\n",
+			strings.Join(referenced, ", "))
+	}
+	return nil
+}
+
+//ExpandCommand splits a job command into arguments following the quoting rules of a POSIX
+// shell and substitutes $NAME, ${NAME} and ${NAME:-default} with the values of vars. The
+// default is used if the value is empty. Substituted values are never split, so a value
+// containing spaces is a single argument, and an unquoted argument that is empty once
+// substituted is dropped. Nothing is substituted within single quotes. Names that aren't in
+// vars are reported as an error, whether or not they have a default
+func ExpandCommand(command string, vars map[string]string) ([]string, error) {
+	e := &commandExpander{command: command, vars: vars}
+	args, err := e.expand()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.undefined) > 0 {
+		sort.Strings(e.undefined)
+		return args, fmt.Errorf("ERROR: The job command references variables that aren't inputs, settings or OUTPUT_DIR: %s\n",
+			strings.Join(e.undefined, ", "))
+	}
+	return args, nil
+}
+
+// commandExpander holds the state of ExpandCommand
+type commandExpander struct {
+	command   string
+	pos       int
+	vars      map[string]string
+	undefined []string
+}
+
+// expand splits the command into arguments, recording the names that aren't in vars
+func (e *commandExpander) expand() ([]string, error) {
+	var args []string
+	for {
+		for e.pos < len(e.command) && isCommandSpace(e.command[e.pos]) {
+			e.pos++
+		}
+		if e.pos >= len(e.command) {
+			break
+		}
+		arg, keep, err := e.word(false)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+func isCommandSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || !first && c >= '0' && c <= '9'
+}
+
+func (e *commandExpander) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("ERROR: Invalid job command %q: %s\n", e.command, fmt.Sprintf(format, args...))
+}
+
+// word reads an argument up to the next unquoted space, or the default of ${NAME:-default} up
+// to the closing brace if inBraces is true. keep is false if the word is only made up of
+// unquoted substitutions that are empty
+func (e *commandExpander) word(inBraces bool) (string, bool, error) {
+	var buffer bytes.Buffer
+	quoted := false
+	for e.pos < len(e.command) {
+		c := e.command[e.pos]
+		switch {
+		case inBraces && c == '}':
+			return buffer.String(), true, nil
+		case !inBraces && isCommandSpace(c):
+			return buffer.String(), quoted || buffer.Len() > 0, nil
+		case c == '\'':
+			end := strings.IndexByte(e.command[e.pos+1:], '\'')
+			if end < 0 {
+				return "", false, e.errorf("unterminated single quote")
+			}
+			buffer.WriteString(e.command[e.pos+1 : e.pos+1+end])
+			e.pos += end + 2
+			quoted = true
+		case c == '"':
+			e.pos++
+			if err := e.doubleQuoted(&buffer); err != nil {
+				return "", false, err
+			}
+			quoted = true
+		case c == '\\' && e.pos+1 < len(e.command):
+			buffer.WriteByte(e.command[e.pos+1])
+			e.pos += 2
+			quoted = true
+		case c == '$':
+			value, err := e.substitute()
+			if err != nil {
+				return "", false, err
+			}
+			buffer.WriteString(value)
+		default:
+			buffer.WriteByte(c)
+			e.pos++
+		}
+	}
+	if inBraces {
+		return "", false, e.errorf("missing } after ${")
+	}
+	return buffer.String(), quoted || buffer.Len() > 0, nil
+}
+
+// doubleQuoted reads the rest of a double quoted string. Backslash only escapes $, ", \ and `
+func (e *commandExpander) doubleQuoted(buffer *bytes.Buffer) error {
+	for e.pos < len(e.command) {
+		c := e.command[e.pos]
+		switch {
+		case c == '"':
+			e.pos++
+			return nil
+		case c == '\\' && e.pos+1 < len(e.command) && strings.IndexByte("$\"\\`", e.command[e.pos+1]) >= 0:
+			buffer.WriteByte(e.command[e.pos+1])
+			e.pos += 2
+		case c == '$':
+			value, err := e.substitute()
+			if err != nil {
+				return err
+			}
+			buffer.WriteString(value)
+		default:
+			buffer.WriteByte(c)
+			e.pos++
+		}
+	}
+	return e.errorf("unterminated double quote")
+}
+
+// substitute reads $NAME, ${NAME} or ${NAME:-default} and returns its value. A $ that isn't
+// followed by a name is kept as is
+func (e *commandExpander) substitute() (string, error) {
+	e.pos++
+	braces := e.pos < len(e.command) && e.command[e.pos] == '{'
+	if braces {
+		e.pos++
+	}
+	start := e.pos
+	for e.pos < len(e.command) && isNameChar(e.command[e.pos], e.pos == start) {
+		e.pos++
+	}
+	name := e.command[start:e.pos]
+	if !braces {
+		if name == "" {
+			return "$", nil
+		}
+		return e.lookup(name), nil
+	}
+
+	if name == "" {
+		return "", e.errorf("missing variable name after ${")
+	}
+	if strings.HasPrefix(e.command[e.pos:], "}") {
+		e.pos++
+		return e.lookup(name), nil
+	}
+	if !strings.Contains(e.command[e.pos:], "}") {
+		return "", e.errorf("missing } after ${")
+	}
+	if !strings.HasPrefix(e.command[e.pos:], ":-") {
+		return "", e.errorf("unsupported substitution ${%s%s, expected ${%s} or ${%s:-default}", name,
+			strings.SplitN(e.command[e.pos:], "}", 2)[0], name, name)
+	}
+
+	// the default is only resolved if it's used
+	e.pos += 2
+	undefined := len(e.undefined)
+	def, _, err := e.word(true)
+	if err != nil {
+		return "", err
+	}
+	e.pos++
+	// a name that isn't in vars is undefined even with a default, as it's likely misspelt
+	if value := e.lookup(name); value != "" {
+		e.undefined = e.undefined[:undefined]
+		return value, nil
+	}
+	return def, nil
+}
+
+// lookup returns the value of a variable, recording it as undefined if it isn't in vars
+func (e *commandExpander) lookup(name string) string {
+	value, ok := e.vars[util.GetNormalizedVariable(name)]
+	if !ok {
+		for _, u := range e.undefined {
+			if u == name {
+				return ""
+			}
+		}
+		e.undefined = append(e.undefined, name)
+	}
+	return value
+}
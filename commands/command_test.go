@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestExpandCommand(t *testing.T) {
+	vars := map[string]string{
+		"INPUT_FILE":   "/data/my inputs/a.tif",
+		"KEY":          "short",
+		"KEY_TWO":      "long",
+		"OPTIONS":      `{"mode": "fast"}`,
+		"OUTPUT_DIR":   "/out",
+		"OPTIONAL":     "",
+		"VERSION_NAME": "1.0",
+	}
+
+	cases := []struct {
+		command  string
+		expected string
+		errorMsg string
+	}{
+		{"${INPUT_FILE} ${OUTPUT_DIR}", `["/data/my inputs/a.tif" "/out"]`, ""},
+		{"run.sh $KEY $KEY_TWO ${KEY}_TWO $KEY-x", `["run.sh" "short" "long" "short_TWO" "short-x"]`, ""},
+		{"run.sh -i=$INPUT_FILE --options $OPTIONS", `["run.sh" "-i=/data/my inputs/a.tif" "--options" "{\"mode\": \"fast\"}"]`, ""},
+		{"run.sh $OPTIONAL ${OPTIONAL} \"$OPTIONAL\" x${OPTIONAL}", `["run.sh" "" "x"]`, ""},
+		{"run.sh ${OPTIONAL:-default} ${KEY:-default} ${OPTIONAL:-$KEY_TWO/x} ${OPTIONAL:-a b}", `["run.sh" "default" "short" "long/x" "a b"]`, ""},
+		{"run.sh ${OPTIONAL:-$UNKNOWN} ${KEY:-$UNKNOWN_TWO} ${UNKNOWN_THREE:-x}", "", "OUTPUT_DIR: UNKNOWN, UNKNOWN_THREE\n"},
+		{"run.sh ${MISSING:-$KEY}", "", "OUTPUT_DIR: MISSING\n"},
+		{"sh -c 'echo $HOME > $OUTPUT_DIR/home.txt'", `["sh" "-c" "echo $HOME > $OUTPUT_DIR/home.txt"]`, ""},
+		{"sh -c \"echo \\$HOME \\\"$KEY\\\" > $OUTPUT_DIR/out.txt\"", `["sh" "-c" "echo $HOME \"short\" > /out/out.txt"]`, ""},
+		{"run.sh a\\ b $ $1 100$", `["run.sh" "a b" "$" "$1" "100$"]`, ""},
+		{"  run.sh\t$version_name  ", `["run.sh" "1.0"]`, ""},
+		{"run.sh $UNKNOWN ${OTHER} $UNKNOWN", "", "variables that aren't inputs, settings or OUTPUT_DIR: OTHER, UNKNOWN\n"},
+		{"run.sh 'unterminated", "", "unterminated single quote"},
+		{"run.sh \"unterminated", "", "unterminated double quote"},
+		{"run.sh ${KEY", "", "missing } after ${"},
+		{"run.sh ${KEY:-x", "", "missing } after ${"},
+		{"run.sh ${}", "", "missing variable name after ${"},
+		{"run.sh ${KEY:=x}", "", "unsupported substitution ${KEY:=x, expected ${KEY} or ${KEY:-default}"},
+	}
+
+	for _, c := range cases {
+		args, err := ExpandCommand(c.command, vars)
+		if c.errorMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), c.errorMsg) {
+				t.Errorf("ExpandCommand(%q) == %v, expected %v", c.command, err, c.errorMsg)
+			}
+			continue
+		}
+		if result := fmt.Sprintf("%q", args); err != nil || result != c.expected {
+			t.Errorf("ExpandCommand(%q) == %q, %v, expected %v", c.command, args, err, c.expected)
+		}
+	}
+}
+
+func TestCommandVars(t *testing.T) {
+	seed := objects.Seed{}
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "INPUT_FILE"}, {Name: "optional-file"}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "COUNT", Type: "integer"}}
+	env := []string{"INPUT_FILE=/data/a.tif", "OUTPUT_DIR=/out", "SECRET_NAME", "EQUATION=a=b"}
+
+	vars := CommandVars(&seed, env)
+	expected := "map[COUNT: EQUATION:a=b INPUT_FILE:/data/a.tif OPTIONAL_FILE: OUTPUT_DIR:/out]"
+	if result := fmt.Sprintf("%v", vars); result != expected {
+		t.Errorf("CommandVars(%q) == %v, expected %v", env, result, expected)
+	}
+}
+
+func TestCheckCommandSecrets(t *testing.T) {
+	secrets := map[string]string{"DB_PASSWORD": "hunter2", "TOKEN": "s3cr3t"}
+	cases := []struct {
+		command  string
+		expected string
+	}{
+		{"./run.sh ${INPUT_FILE} $OUTPUT_DIR", ""},
+		{"./run.sh -p $DB_PASSWORD", "DB_PASSWORD"},
+		{"./run.sh \"--token=${token}\" ${DB_PASSWORD:-none}", "DB_PASSWORD, token"},
+		{"./run.sh '$TOKEN' \\$DB_PASSWORD", ""},
+		{"./run.sh \"$TOKEN", "unterminated double quote"},
+	}
+
+	for _, c := range cases {
+		err := CheckCommandSecrets(c.command, secrets)
+		if (c.expected == "") != (err == nil) || err != nil && !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("CheckCommandSecrets(%q) == %v, expected %v", c.command, err, c.expected)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//JsonType returns the JSON type of a value decoded with json.Decoder.UseNumber: string,
+// integer, number, boolean, object, array or null
+func JsonType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		if f, _, err := big.ParseFloat(v.String(), 10, 256, big.ToNearestEven); err == nil && f.IsInt() {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "null"
+}
+
+// jsonTypeMatches returns true if a value of type actual may be given for an input of the
+// declared type. Integers are numbers, and inputs of an unknown type accept any value
+func jsonTypeMatches(declared, actual string) bool {
+	switch declared {
+	case "string", "integer", "boolean", "object", "array":
+		return declared == actual
+	case "number":
+		return actual == "number" || actual == "integer"
+	}
+	return true
+}
+
+// article returns the type name preceded by a or an
+func article(jsonType string) string {
+	if strings.IndexAny(jsonType[:1], "aeiou") == 0 {
+		return "an " + jsonType
+	}
+	return "a " + jsonType
+}
+
+// jsonInputValue returns the value of a json input given as -j KEY=VALUE after checking it is
+// of the type declared by the seed manifest. A value starting with @ names a file to read the
+// value from and @@ starts a literal value beginning with @. Values of string inputs are used
+// as given; other values must be JSON and are passed compacted
+func jsonInputValue(in objects.InJson, value string) (string, error) {
+	prefix := constants.JsonFilePrefix
+	source := ""
+	if strings.HasPrefix(value, prefix+prefix) {
+		value = value[len(prefix):]
+	} else if strings.HasPrefix(value, prefix) {
+		fileName := value[len(prefix):]
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return "", fmt.Errorf("%s: unable to read %s: %s", in.Name, fileName, err.Error())
+		}
+		if in.Type == "string" {
+			return strings.TrimRight(string(data), "\r\n"), nil
+		}
+		value = string(data)
+		source = " in " + fileName
+	}
+	if in.Type == "string" {
+		return value, nil
+	}
+
+	// values that aren't JSON are text, which is a string
+	actual := "string"
+	isJson := json.Valid([]byte(value))
+	if isJson {
+		var decoded interface{}
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		decoder.Decode(&decoded)
+		actual = JsonType(decoded)
+	}
+	if !jsonTypeMatches(in.Type, actual) {
+		shown := strings.TrimSpace(value)
+		if len(shown) > 40 {
+			shown = shown[:37] + "..."
+		}
+		return "", fmt.Errorf("%s: expected %s, got %s%s: %s", in.Name, article(in.Type), article(actual), source, shown)
+	}
+	if !isJson {
+		return value, nil
+	}
+
+	compact := new(bytes.Buffer)
+	json.Compact(compact, []byte(value))
+	return compact.String(), nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestJsonInputValue(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-json-")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "options.json"), []byte("{\n  \"mode\": \"fast\",\n  \"bands\": [1, 2]\n}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "label.txt"), []byte("first, second\n"), 0644)
+
+	cases := []struct {
+		jsonType string
+		value    string
+		expected string
+		errorMsg string
+	}{
+		{"integer", "3", "3", ""},
+		{"integer", "3.0", "3.0", ""},
+		{"integer", "2.5", "", "COUNT: expected an integer, got a number: 2.5"},
+		{"integer", "three", "", "COUNT: expected an integer, got a string: three"},
+		{"number", "2", "2", ""},
+		{"number", "1e-3", "1e-3", ""},
+		{"number", "true", "", "COUNT: expected a number, got a boolean: true"},
+		{"boolean", "false", "false", ""},
+		{"string", "{a: 1, b: 2}", "{a: 1, b: 2}", ""},
+		{"string", "@$DIR$/label.txt", "first, second", ""},
+		{"string", "@@me", "@me", ""},
+		{"object", "{ \"a\": 1 }", "{\"a\":1}", ""},
+		{"object", "@$DIR$/options.json", "{\"mode\":\"fast\",\"bands\":[1,2]}", ""},
+		{"object", "[1, 2]", "", "COUNT: expected an object, got an array: [1, 2]"},
+		{"object", "{a: 1}", "", "COUNT: expected an object, got a string: {a: 1}"},
+		{"array", "@$DIR$/options.json", "", "COUNT: expected an array, got an object in $DIR$/options.json: {"},
+		{"array", "@$DIR$/missing.json", "", "COUNT: unable to read $DIR$/missing.json"},
+		{"array", "null", "", "COUNT: expected an array, got a null: null"},
+		{"", "anything", "anything", ""},
+	}
+
+	for _, c := range cases {
+		in := objects.InJson{Name: "COUNT", Type: c.jsonType}
+		value := strings.Replace(c.value, "$DIR$", dir, -1)
+		errorMsg := strings.Replace(c.errorMsg, "$DIR$", dir, -1)
+		result, err := jsonInputValue(in, value)
+		if errorMsg != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), errorMsg) {
+				t.Errorf("jsonInputValue(%q, %q) == %v, expected %v", c.jsonType, value, err, errorMsg)
+			}
+			continue
+		}
+		if err != nil || result != c.expected {
+			t.Errorf("jsonInputValue(%q, %q) == %v, %v, expected %v", c.jsonType, value, result, err, c.expected)
+		}
+	}
+}
@@ -2,27 +2,53 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os/exec"
 	"strings"
 
 	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-common/util"
 )
 
+//ListResult is the document written by seed list for the json and yaml formats
+type ListResult struct {
+	Images []ImageSummary `json:"images"`
+}
+
+//ImageSummary describes a seed image found on the local system
+type ImageSummary struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	ImageID    string `json:"imageId"`
+	Created    string `json:"created"`
+	Size       string `json:"size"`
+}
+
+// imageListFormat is the template docker and podman images print each image with for the
+// json and yaml formats: an ImageSummary as a json object on a line of its own
+const imageListFormat = `{"repository":{{json .Repository}},"tag":{{json .Tag}},"imageId":{{json .ID}},` +
+	`"created":{{json .CreatedSince}},"size":{{json .Size}}}`
+
 //DockerList - Simplified version of dockerlist - relies on name filter of
 //  docker images command to search for images ending in '-seed'
-func DockerList() (string, error) {
+// The images found are printed as text or written to stdout as a json or yaml ListResult
+func DockerList(format string) (string, error) {
 	var errs, out bytes.Buffer
 	var cmd *exec.Cmd
-	reference := util.DockerVersionHasReferenceFilter()
-	var buildArgs, dockerCommand = cliutil.DockerCommandArgsInit()
+	reference := cliutil.Runtime().SupportsReferenceFilter()
+	var buildArgs, dockerCommand = cliutil.Runtime().CommandArgsInit()
+	buildArgs = append(buildArgs, "images")
+	if IsStructuredFormat(format) {
+		buildArgs = append(buildArgs, "--format", imageListFormat)
+	}
 	if reference {
-		buildArgs = append(buildArgs, "images", "--filter=reference=*-seed*")
+		buildArgs = append(buildArgs, "--filter=reference=*-seed*")
 		cmd = exec.Command(dockerCommand, buildArgs...)
 	} else {
-		buildArgs = append(buildArgs, "images")
 		dCmd := exec.Command(dockerCommand, buildArgs...)
 		cmd = exec.Command("grep", "-seed")
 		var dErr bytes.Buffer
@@ -62,6 +88,15 @@ func DockerList() (string, error) {
 		return "", errors.New(errs.String())
 	}
 
+	if IsStructuredFormat(format) {
+		images, err := ParseImageList(out.String())
+		if err != nil {
+			util.PrintUtil("%s", err.Error())
+			return "", err
+		}
+		return out.String(), WriteResult(format, ListResult{Images: images})
+	}
+
 	if !strings.Contains(out.String(), "seed") {
 		util.PrintUtil("No seed images found!\n")
 		return "", nil
@@ -70,9 +105,33 @@ func DockerList() (string, error) {
 	return out.String(), nil
 }
 
+//ParseImageList parses the images printed by docker or podman images with imageListFormat,
+// one json object per line, into image summaries. Images that aren't seed images are skipped
+func ParseImageList(list string) ([]ImageSummary, error) {
+	images := []ImageSummary{}
+	for _, line := range strings.Split(list, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var image ImageSummary
+		if err := json.Unmarshal([]byte(line), &image); err != nil {
+			return images, fmt.Errorf("ERROR: Unable to read the image list %q: %s\n", line, err.Error())
+		}
+		if strings.Contains(image.Repository, "seed") {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
 //PrintListUsage prints the seed list usage information, then exits the program
 func PrintListUsage() {
-	util.PrintUtil("\nUsage:\tseed list\n")
+	util.PrintUtil("\nUsage:\tseed list [-%s FORMAT] [-%s RUNTIME]\n", constants.FormatFlag, constants.RuntimeFlag)
 	util.PrintUtil("\nLists all Seed compliant docker images residing on the local system.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s\tFormat of the image list written to stdout: text, json or yaml (default is text)\n",
+		constants.FormatFlag)
+	util.PrintUtil("  -%s\tContainer runtime to use: docker or podman (default is $%s or docker)\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
 	return
 }
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
 
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-common/util"
 )
 
@@ -25,7 +27,7 @@ func TestDockerList(t *testing.T) {
 		buildArgs := []string{"build", "-t", c.imageName, c.directory}
 		cmd := exec.Command("docker", buildArgs...)
 		cmd.Run()
-		output, err := DockerList()
+		output, err := DockerList(constants.TextFormat)
 		if err != nil {
 			t.Errorf("DockerList returned an error: %v", err)
 		}
@@ -34,3 +36,30 @@ func TestDockerList(t *testing.T) {
 		}
 	}
 }
+
+func TestParseImageList(t *testing.T) {
+	cases := []struct {
+		list     string
+		expected string
+		success  bool
+	}{
+		{`{"repository":"addition-job-0.0.1-seed","tag":"1.0.0","imageId":"0c4bd5c3b7e8","created":"2 days ago","size":"4.41MB"}` + "\n" +
+			`{"repository":"my-registry:5000/extractor-seed","tag":"latest","imageId":"93fd7bd6a4a0","created":"3 weeks ago","size":"1.2 GB"}` + "\n",
+			"[{addition-job-0.0.1-seed 1.0.0 0c4bd5c3b7e8 2 days ago 4.41MB} " +
+				"{my-registry:5000/extractor-seed latest 93fd7bd6a4a0 3 weeks ago 1.2 GB}]", true},
+		{`{"repository":"alpine","tag":"3.8","imageId":"196d12cf6ab1","created":"2 years ago","size":"4.41MB"}` + "\n",
+			"[]", true},
+		{"", "[]", true},
+		{"REPOSITORY   TAG   IMAGE ID   CREATED   SIZE\n", "[]", false},
+	}
+
+	for _, c := range cases {
+		images, err := ParseImageList(c.list)
+		if c.success != (err == nil) {
+			t.Errorf("ParseImageList(%q) == %v, expected success %v", c.list, err, c.success)
+		}
+		if result := fmt.Sprintf("%v", images); err == nil && result != c.expected {
+			t.Errorf("ParseImageList(%q) == %v, expected %v", c.list, result, c.expected)
+		}
+	}
+}
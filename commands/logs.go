@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+//RunLogs saves the output of a container to the job output directory while it is
+// streamed to the terminal. stdout.log and stderr.log hold the output of each stream as
+// written by the job and seed.log holds both streams with each line timestamped and
+// prefixed with its stream. The values of secret settings are written to the log files as ****
+type RunLogs struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Files  []string
+	files  []*os.File
+	masked []*maskWriter
+	stdout *timestampWriter
+	stderr *timestampWriter
+}
+
+//OpenRunLogs creates the log files in the output directory. The returned Stdout and
+// Stderr writers also write to the given stdout and stderr, which may be nil
+func OpenRunLogs(outDir string, stdout, stderr io.Writer, secrets map[string]string) (*RunLogs, error) {
+	logs := &RunLogs{}
+	var open []io.Writer
+	for _, name := range []string{constants.StdoutLogFileName, constants.StderrLogFileName, constants.CombinedLogFileName} {
+		fileName := filepath.Join(outDir, name)
+		f, err := os.Create(fileName)
+		if err != nil {
+			logs.Close()
+			return nil, fmt.Errorf("ERROR: Unable to create log file %s: %s\n", fileName, err.Error())
+		}
+		logs.files = append(logs.files, f)
+		logs.Files = append(logs.Files, fileName)
+		if len(secrets) == 0 {
+			open = append(open, f)
+			continue
+		}
+		masked := &maskWriter{out: f, secrets: secrets}
+		logs.masked = append(logs.masked, masked)
+		open = append(open, masked)
+	}
+
+	// the streams are copied concurrently so writes to the combined log are serialized
+	lock := &sync.Mutex{}
+	logs.stdout = &timestampWriter{out: open[2], stream: "stdout", lock: lock}
+	logs.stderr = &timestampWriter{out: open[2], stream: "stderr", lock: lock}
+	logs.Stdout = io.MultiWriter(withTerminal(stdout, open[0], logs.stdout)...)
+	logs.Stderr = io.MultiWriter(withTerminal(stderr, open[1], logs.stderr)...)
+	return logs, nil
+}
+
+//Close writes any unterminated lines to the log files and closes them
+func (l *RunLogs) Close() error {
+	if l.stdout != nil {
+		l.stdout.Flush()
+		l.stderr.Flush()
+	}
+	for _, m := range l.masked {
+		m.Flush()
+	}
+	var err error
+	for _, f := range l.files {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+// withTerminal returns the writers preceded by terminal if it isn't nil
+func withTerminal(terminal io.Writer, writers ...io.Writer) []io.Writer {
+	if terminal == nil {
+		return writers
+	}
+	return append([]io.Writer{terminal}, writers...)
+}
+
+// timestampWriter writes each line prefixed with the time it was written and its stream
+type timestampWriter struct {
+	out     io.Writer
+	stream  string
+	lock    *sync.Mutex
+	partial []byte
+	now     func() time.Time
+}
+
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(data[:i+1]); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte{}, data...)
+	return len(p), nil
+}
+
+//Flush writes an unterminated last line
+func (w *timestampWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.partial) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.partial, '\n'))
+	w.partial = nil
+	return err
+}
+
+func (w *timestampWriter) writeLine(line []byte) error {
+	now := time.Now()
+	if w.now != nil {
+		now = w.now()
+	}
+	_, err := fmt.Fprintf(w.out, "%s %s | %s", now.UTC().Format("2006-01-02T15:04:05.000Z"), w.stream, line)
+	return err
+}
+
+// maskWriter replaces the values of secrets with **** before writing. Secret values can't
+// contain a newline, so whole lines are written to mask values split across writes
+type maskWriter struct {
+	out     io.Writer
+	secrets map[string]string
+	partial []byte
+}
+
+func (w *maskWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		if _, err := io.WriteString(w.out, MaskSecrets(string(data[:i+1]), w.secrets)); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte{}, data...)
+	return len(p), nil
+}
+
+//Flush writes an unterminated last line
+func (w *maskWriter) Flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.out, MaskSecrets(string(w.partial), w.secrets))
+	w.partial = nil
+	return err
+}
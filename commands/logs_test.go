@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+func TestOpenRunLogs(t *testing.T) {
+	outDir, _ := ioutil.TempDir("", "seed-logs-")
+	defer os.RemoveAll(outDir)
+
+	var terminal bytes.Buffer
+	logs, err := OpenRunLogs(outDir, &terminal, nil, nil)
+	if err != nil {
+		t.Fatalf("OpenRunLogs(%q) returned an error: %v", outDir, err)
+	}
+	now := func() time.Time { return time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC) }
+	logs.stdout.now, logs.stderr.now = now, now
+
+	logs.Stdout.Write([]byte("adding 1"))
+	logs.Stderr.Write([]byte("warning: 100% of inputs\n"))
+	logs.Stdout.Write([]byte(" and 2\n3"))
+	logs.Close()
+
+	cases := []struct {
+		file     string
+		expected string
+	}{
+		{constants.StdoutLogFileName, "adding 1 and 2\n3"},
+		{constants.StderrLogFileName, "warning: 100% of inputs\n"},
+		{constants.CombinedLogFileName, "2018-01-02T03:04:05.000Z stderr | warning: 100% of inputs\n" +
+			"2018-01-02T03:04:05.000Z stdout | adding 1 and 2\n" +
+			"2018-01-02T03:04:05.000Z stdout | 3\n"},
+	}
+	for _, c := range cases {
+		data, _ := ioutil.ReadFile(filepath.Join(outDir, c.file))
+		if string(data) != c.expected {
+			t.Errorf("OpenRunLogs wrote %q to %s, expected %q", string(data), c.file, c.expected)
+		}
+	}
+	if terminal.String() != "adding 1 and 2\n3" {
+		t.Errorf("OpenRunLogs wrote %q to the terminal, expected %q", terminal.String(), "adding 1 and 2\n3")
+	}
+	if len(logs.Files) != 3 {
+		t.Errorf("OpenRunLogs created %v, expected 3 log files", logs.Files)
+	}
+
+	if _, err := OpenRunLogs(filepath.Join(outDir, "missing"), nil, nil, nil); err == nil {
+		t.Errorf("OpenRunLogs(%q) == nil, expected an error", filepath.Join(outDir, "missing"))
+	}
+}
+
+func TestRunLogsMaskSecrets(t *testing.T) {
+	outDir, _ := ioutil.TempDir("", "seed-logs-")
+	defer os.RemoveAll(outDir)
+
+	logs, err := OpenRunLogs(outDir, nil, nil, map[string]string{"DB_PASS": "hunter2", "TOKEN": "s3cr3t"})
+	if err != nil {
+		t.Fatalf("OpenRunLogs(%q) returned an error: %v", outDir, err)
+	}
+	logs.Stdout.Write([]byte("connecting with hun"))
+	logs.Stdout.Write([]byte("ter2\ntoken s3"))
+	logs.Stderr.Write([]byte("invalid token s3cr3t\n"))
+	logs.Stdout.Write([]byte("cr3t"))
+	logs.Close()
+
+	for _, file := range logs.Files {
+		data, _ := ioutil.ReadFile(file)
+		if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "s3cr3t") {
+			t.Errorf("OpenRunLogs wrote a secret to %s: %q", filepath.Base(file), string(data))
+		}
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(outDir, constants.CombinedLogFileName))
+	if strings.Count(string(data), "****") != 3 {
+		t.Errorf("OpenRunLogs wrote %q to %s, expected 3 masked secrets", string(data), constants.CombinedLogFileName)
+	}
+	data, _ = ioutil.ReadFile(filepath.Join(outDir, constants.StdoutLogFileName))
+	if string(data) != "connecting with ****\ntoken ****" {
+		t.Errorf("OpenRunLogs wrote %q to %s, expected %q", string(data), constants.StdoutLogFileName,
+			"connecting with ****\ntoken ****")
+	}
+}
+
+func TestIsSeedOutputFile(t *testing.T) {
+	cases := []struct {
+		file     string
+		expected bool
+	}{
+		{"/out/stdout.log", true},
+		{"/out/seed.log", true},
+		{"/out/seed.run.json", true},
+		{"/out/job.log", false},
+		{"/out/sub/stdout.log", false},
+		{"/out/seed.outputs.json", false},
+	}
+
+	for _, c := range cases {
+		result := isSeedOutputFile("/out", filepath.FromSlash(c.file))
+		if result != c.expected {
+			t.Errorf("isSeedOutputFile(%q, %q) == %v, expected %v", "/out", c.file, result, c.expected)
+		}
+	}
+}
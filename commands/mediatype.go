@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// media type reported by http.DetectContentType when the content is not recognized
+const unknownMediaType = "application/octet-stream"
+
+//MediaTypeMismatch describes an input file whose detected media type does not match
+// any of the mediaTypes declared for its input in the seed manifest
+type MediaTypeMismatch struct {
+	Input    string
+	File     string
+	Detected string
+	Expected []string
+}
+
+func (m MediaTypeMismatch) String() string {
+	return fmt.Sprintf("Input %s file %s has media type %s, expected one of %s", m.Input, m.File, m.Detected,
+		strings.Join(m.Expected, ", "))
+}
+
+//CheckInputMediaTypes compares the media types of the given input files against the
+// mediaTypes declared for each input in the seed manifest. Every file inside a directory
+// given for a multiple input or matching one of its patterns is checked. Files whose media
+// type can't be determined and inputs that don't declare any media types are skipped
+func CheckInputMediaTypes(seed *objects.Seed, inputs []string) ([]MediaTypeMismatch, error) {
+	var mismatches []MediaTypeMismatch
+
+	inValues := inputValues(inputs, true)
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		key := util.GetNormalizedVariable(f.Name)
+		values, ok := inValues[key]
+		if !ok || len(f.MediaTypes) == 0 {
+			continue
+		}
+
+		paths, err := ExpandInputPaths(values, f.Multiple)
+		if err != nil {
+			return mismatches, err
+		}
+		var files []string
+		for _, p := range paths {
+			err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return mismatches, fmt.Errorf("ERROR: Unable to read input %s: %s", key, err.Error())
+			}
+		}
+
+		for _, file := range files {
+			detected, err := DetectMediaType(file)
+			if err != nil {
+				return mismatches, fmt.Errorf("ERROR: Unable to read input %s: %s", key, err.Error())
+			}
+			if detected == "" || MatchMediaType(f.MediaTypes, detected) {
+				continue
+			}
+			mismatches = append(mismatches, MediaTypeMismatch{Input: key, File: file,
+				Detected: detected, Expected: f.MediaTypes})
+		}
+	}
+
+	return mismatches, nil
+}
+
+//DetectMediaType returns the media type of a file determined by sniffing its content.
+// The file extension is not consulted, so the result doesn't depend on the mime tables of
+// the host. An empty string is returned if the content is not recognized
+func DetectMediaType(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+	sniffed := baseMediaType(http.DetectContentType(buf[:n]))
+	if sniffed == unknownMediaType {
+		return "", nil
+	}
+
+	return sniffed, nil
+}
+
+//MatchMediaType returns true if the detected media type satisfies one of the
+// declared media types. Declared types may be a top level type only (e.g. text),
+// a wildcard (e.g. image/*) or a structured syntax type (e.g. application/geo+json
+// matches application/json)
+func MatchMediaType(declared []string, detected string) bool {
+	t := baseMediaType(detected)
+	for _, d := range declared {
+		d = baseMediaType(d)
+		switch {
+		case d == unknownMediaType || d == "*/*" || d == "*":
+			return true
+		case d == t:
+			return true
+		case !strings.Contains(d, "/") && strings.HasPrefix(t, d+"/"):
+			return true
+		case strings.HasSuffix(d, "/*") && strings.HasPrefix(t, strings.TrimSuffix(d, "*")):
+			return true
+		case strings.Contains(d, "+") && t == "application/"+d[strings.LastIndex(d, "+")+1:]:
+			return true
+		}
+	}
+	return false
+}
+
+// baseMediaType strips any parameters (e.g. charset) from a media type
+func baseMediaType(mediaType string) string {
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+func TestCheckInputMediaTypes(t *testing.T) {
+	cases := []struct {
+		seedFileName       string
+		inputs             []string
+		expectedMismatches int
+		expected           bool
+	}{
+		{"../examples/addition-job/seed.manifest.json",
+			[]string{"INPUT_FILE=../examples/addition-job/inputs.txt"}, 0, true},
+		{"../examples/addition-job/seed.manifest.json",
+			[]string{"INPUT_FILE=../testdata/seed-scale.zip"}, 1, true},
+		{"../examples/multi-addition-job/seed.manifest.json",
+			[]string{"INPUT_FILE=../examples/multi-addition-job/inputs"}, 0, true},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../testdata/seed-scale.zip", "MULTIPLE=../examples/multi-addition-job/inputs"}, 0, true},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../examples/addition-job/inputs.txt", "MULTIPLE=../testdata"}, 2, true},
+		{"../examples/addition-job/seed.manifest.json",
+			[]string{"INPUT_FILE=../examples/addition-job/missing.txt"}, 0, false},
+	}
+
+	for _, c := range cases {
+		seedFileName := util.GetFullPath(c.seedFileName, "")
+		seed := objects.SeedFromManifestFile(seedFileName)
+		mismatches, err := CheckInputMediaTypes(&seed, c.inputs)
+		if c.expected != (err == nil) {
+			t.Errorf("CheckInputMediaTypes(%q, %q) == %v, expected %v", seedFileName, c.inputs, err, c.expected)
+		}
+		if len(mismatches) != c.expectedMismatches {
+			t.Errorf("CheckInputMediaTypes(%q, %q) == %v, expected %v mismatches", seedFileName, c.inputs,
+				mismatches, c.expectedMismatches)
+		}
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	cases := []struct {
+		declared []string
+		detected string
+		expected bool
+	}{
+		{[]string{"text/plain"}, "text/plain", true},
+		{[]string{"text/plain"}, "text/plain; charset=utf-8", true},
+		{[]string{"Text/Plain"}, "text/plain", true},
+		{[]string{"text"}, "text/csv", true},
+		{[]string{"image/*"}, "image/tiff", true},
+		{[]string{"application/geo+json"}, "application/json", true},
+		{[]string{"application/octet-stream"}, "image/png", true},
+		{[]string{"image/png", "image/tiff"}, "image/tiff", true},
+		{[]string{"text/plain"}, "application/zip", false},
+		{[]string{"text"}, "textual/plain", false},
+		{[]string{"image/*"}, "application/pdf", false},
+	}
+
+	for _, c := range cases {
+		result := MatchMediaType(c.declared, c.detected)
+		if result != c.expected {
+			t.Errorf("MatchMediaType(%q, %q) == %v, expected %v", c.declared, c.detected, result, c.expected)
+		}
+	}
+}
+
+func TestDetectMediaType(t *testing.T) {
+	cases := []struct {
+		file     string
+		expected string
+	}{
+		{"../examples/addition-job/inputs.txt", "text/plain"},
+		{"../testdata/seed-scale.zip", "application/zip"},
+		{"../examples/extractor/seed.png.metadata.json", "text/plain"},
+	}
+
+	for _, c := range cases {
+		result, err := DetectMediaType(c.file)
+		if err != nil || result != c.expected {
+			t.Errorf("DetectMediaType(%q) == %v, %v, expected %v", c.file, result, err, c.expected)
+		}
+	}
+}
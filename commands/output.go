@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"gopkg.in/yaml.v2"
+)
+
+// resultWriter receives the documents written for the json and yaml formats. Logs and
+// progress are always written to stderr so the documents can be piped to other tools
+var resultWriter io.Writer = os.Stdout
+
+//CheckFormat returns an error if format is not one of the supported output formats.
+// An empty format is treated as text
+func CheckFormat(format string) error {
+	switch format {
+	case "", constants.TextFormat, constants.JsonFormat, constants.YamlFormat:
+		return nil
+	}
+	return fmt.Errorf("ERROR: Unknown output format %s. Supported formats are %s, %s and %s.\n",
+		format, constants.TextFormat, constants.JsonFormat, constants.YamlFormat)
+}
+
+//IsStructuredFormat returns true if results should be written as a json or yaml document
+func IsStructuredFormat(format string) bool {
+	return format == constants.JsonFormat || format == constants.YamlFormat
+}
+
+//WriteResult writes the given result to stdout as a json or yaml document. Nothing is
+// written for the text format. The yaml document uses the same keys as the json document
+func WriteResult(format string, result interface{}) error {
+	if !IsStructuredFormat(format) {
+		return nil
+	}
+
+	doc, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ERROR: Error encoding %s result: %s\n", format, err.Error())
+	}
+
+	if format == constants.YamlFormat {
+		// json is a subset of yaml; round trip through a MapSlice to keep the key order
+		var ordered yaml.MapSlice
+		if err = yaml.Unmarshal(doc, &ordered); err == nil {
+			doc, err = yaml.Marshal(ordered)
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: Error encoding %s result: %s\n", format, err.Error())
+		}
+	} else {
+		doc = append(doc, '\n')
+	}
+
+	_, err = resultWriter.Write(doc)
+	return err
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+func TestCheckFormat(t *testing.T) {
+	cases := []struct {
+		format   string
+		expected bool
+	}{
+		{"", true},
+		{constants.TextFormat, true},
+		{constants.JsonFormat, true},
+		{constants.YamlFormat, true},
+		{"xml", false},
+		{"JSON", false},
+	}
+
+	for _, c := range cases {
+		err := CheckFormat(c.format)
+		if c.expected != (err == nil) {
+			t.Errorf("CheckFormat(%q) == %v, expected %v", c.format, err, c.expected)
+		}
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	result := SearchResult{Registry: "localhost:5000", Repositories: []string{"addition-job-0.0.1-seed:1.0.0"}}
+	cases := []struct {
+		format   string
+		expected string
+	}{
+		{constants.TextFormat, ""},
+		{constants.JsonFormat, "{\n  \"registry\": \"localhost:5000\",\n  \"repositories\": [\n" +
+			"    \"addition-job-0.0.1-seed:1.0.0\"\n  ]\n}\n"},
+		{constants.YamlFormat, "registry: localhost:5000\nrepositories:\n- addition-job-0.0.1-seed:1.0.0\n"},
+	}
+
+	stdout := resultWriter
+	defer func() { resultWriter = stdout }()
+	for _, c := range cases {
+		var out bytes.Buffer
+		resultWriter = &out
+		err := WriteResult(c.format, result)
+		if err != nil || out.String() != c.expected {
+			t.Errorf("WriteResult(%q, %v) == %q, %v, expected %q", c.format, result, out.String(), err, c.expected)
+		}
+	}
+}
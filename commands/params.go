@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+	"gopkg.in/yaml.v2"
+)
+
+//JobParams holds the inputs, json inputs, settings and mounts of a run, keyed by their names
+// in the seed manifest. Input files may be a single path or a list of paths for multiple
+// inputs, and json inputs may be any JSON value
+type JobParams struct {
+	Inputs   map[string]interface{} `json:"inputs" yaml:"inputs"`
+	Json     map[string]interface{} `json:"json" yaml:"json"`
+	Settings map[string]interface{} `json:"settings" yaml:"settings"`
+	Mounts   map[string]string      `json:"mounts" yaml:"mounts"`
+	// dir is the directory of the parameter file. Relative paths are relative to it
+	dir string
+}
+
+//ReadJobParams reads a job parameter file. Files with a .json extension are read as JSON
+// and any other file as YAML
+func ReadJobParams(fileName string) (*JobParams, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to read job parameters %s: %s\n", fileName, err.Error())
+	}
+
+	params := &JobParams{dir: filepath.Dir(util.GetFullPath(fileName, ""))}
+	if strings.EqualFold(filepath.Ext(fileName), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		decoder.UseNumber()
+		err = decoder.Decode(params)
+	} else {
+		err = yaml.UnmarshalStrict(data, params)
+		for key, value := range params.Json {
+			params.Json[key] = jsonValue(value)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Invalid job parameters %s: %s\n", fileName, err.Error())
+	}
+	return params, nil
+}
+
+// jsonValue converts the maps decoded from YAML, which have interface{} keys, to maps with
+// string keys so the value can be encoded as JSON
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+	return value
+}
+
+//Validate checks that every parameter is declared by the interface of the seed manifest and
+// that only multiple inputs are given more than one file
+func (p *JobParams) Validate(seed *objects.Seed) error {
+	files := map[string]bool{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		files[util.GetNormalizedVariable(f.Name)] = f.Multiple
+	}
+	jsonNames := map[string]bool{}
+	for _, j := range seed.Job.Interface.Inputs.Json {
+		jsonNames[util.GetNormalizedVariable(j.Name)] = true
+	}
+	settings := map[string]bool{}
+	for _, s := range seed.Job.Interface.Settings {
+		settings[util.GetNormalizedVariable(s.Name)] = true
+	}
+	mounts := map[string]bool{}
+	for _, m := range seed.Job.Interface.Mounts {
+		mounts[util.GetNormalizedVariable(m.Name)] = true
+	}
+
+	var errs []string
+	for _, name := range sortedNames(p.Inputs) {
+		multiple, ok := files[util.GetNormalizedVariable(name)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("inputs: %s is not an input file of the seed manifest", name))
+			continue
+		}
+		switch value := p.Inputs[name].(type) {
+		case string:
+		case []interface{}:
+			if !multiple && len(value) != 1 {
+				errs = append(errs, fmt.Sprintf("inputs: %s accepts a single file", name))
+			}
+			for _, v := range value {
+				if _, isString := v.(string); !isString {
+					errs = append(errs, fmt.Sprintf("inputs: %s must be a list of paths", name))
+					break
+				}
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("inputs: %s must be a path or a list of paths", name))
+		}
+	}
+	for _, name := range sortedNames(p.Json) {
+		if !jsonNames[util.GetNormalizedVariable(name)] {
+			errs = append(errs, fmt.Sprintf("json: %s is not a json input of the seed manifest", name))
+		}
+	}
+	for _, name := range sortedNames(p.Settings) {
+		if !settings[util.GetNormalizedVariable(name)] {
+			errs = append(errs, fmt.Sprintf("settings: %s is not a setting of the seed manifest", name))
+			continue
+		}
+		switch p.Settings[name].(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			errs = append(errs, fmt.Sprintf("settings: %s must be a single value", name))
+		}
+	}
+	for name := range p.Mounts {
+		if !mounts[util.GetNormalizedVariable(name)] {
+			errs = append(errs, fmt.Sprintf("mounts: %s is not a mount of the seed manifest", name))
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("ERROR: The job parameters don't match the seed manifest:\n  %s\n", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+//Args returns the parameters as the KEY=VALUE arguments of the inputs, json, settings and
+// mounts flags. Relative paths, including the files of json inputs given as @FILE, are resolved
+// against the directory of the parameter file and json inputs that aren't strings are given as JSON
+func (p *JobParams) Args() ([]string, []string, []string, []string, error) {
+	var inputs, jsonArgs, settings, mounts []string
+	for _, name := range sortedNames(p.Inputs) {
+		values := []interface{}{p.Inputs[name]}
+		if list, ok := p.Inputs[name].([]interface{}); ok {
+			values = list
+		}
+		for _, v := range values {
+			inputs = append(inputs, name+"="+p.path(fmt.Sprint(v)))
+		}
+	}
+	for _, name := range sortedNames(p.Json) {
+		value, ok := p.Json[name].(string)
+		prefix := constants.JsonFilePrefix
+		if ok && strings.HasPrefix(value, prefix) && !strings.HasPrefix(value, prefix+prefix) {
+			value = prefix + p.path(value[len(prefix):])
+		} else if !ok {
+			data, err := json.Marshal(p.Json[name])
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("ERROR: Invalid json input %s: %s\n", name, err.Error())
+			}
+			value = string(data)
+		}
+		jsonArgs = append(jsonArgs, name+"="+value)
+	}
+	for _, name := range sortedNames(p.Settings) {
+		value := ""
+		if p.Settings[name] != nil {
+			value = fmt.Sprint(p.Settings[name])
+		}
+		settings = append(settings, name+"="+value)
+	}
+	var mountNames []string
+	for name := range p.Mounts {
+		mountNames = append(mountNames, name)
+	}
+	sort.Strings(mountNames)
+	for _, name := range mountNames {
+		mounts = append(mounts, name+"="+p.path(p.Mounts[name]))
+	}
+	return inputs, jsonArgs, settings, mounts, nil
+}
+
+// path resolves a path relative to the directory of the parameter file. URIs are returned as given
+func (p *JobParams) path(value string) string {
+	if remote.IsURI(value) || filepath.IsAbs(value) {
+		return value
+	}
+	return filepath.Join(p.dir, value)
+}
+
+//ApplyJobParams reads and validates the job parameter file and adds its parameters to the
+// given inputs, json, settings and mounts flag values. A parameter given by a flag overrides
+// the parameter of the same name in the file
+func ApplyJobParams(seed *objects.Seed, fileName string, inputs, jsonArgs, settings, mounts []string) ([]string, []string, []string, []string, error) {
+	params, err := ReadJobParams(fileName)
+	if err != nil {
+		return inputs, jsonArgs, settings, mounts, err
+	}
+	if err = params.Validate(seed); err != nil {
+		return inputs, jsonArgs, settings, mounts, err
+	}
+	pInputs, pJson, pSettings, pMounts, err := params.Args()
+	if err != nil {
+		return inputs, jsonArgs, settings, mounts, err
+	}
+
+	return overrideArgs(pInputs, inputs), overrideArgs(pJson, jsonArgs), overrideArgs(pSettings, settings),
+		overrideArgs(pMounts, mounts), nil
+}
+
+// overrideArgs returns the KEY=VALUE arguments of the parameter file whose keys aren't given by
+// the flags, followed by the flags
+func overrideArgs(params, flags []string) []string {
+	given := inputValues(flags, true)
+	var args []string
+	for _, p := range params {
+		key := util.GetNormalizedVariable(strings.SplitN(p, "=", 2)[0])
+		if _, ok := given[key]; !ok {
+			args = append(args, p)
+		}
+	}
+	for _, f := range flags {
+		if f != "" {
+			args = append(args, f)
+		}
+	}
+	return args
+}
+
+// sortedNames returns the keys of a parameter map in sorted order
+func sortedNames(m map[string]interface{}) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
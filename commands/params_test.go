@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+func TestApplyJobParams(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seed-params-")
+	defer os.RemoveAll(dir)
+
+	seed := objects.Seed{}
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "IMAGE"}, {Name: "BANDS", Multiple: true}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "OPTIONS", Type: "object"}, {Name: "LABEL", Type: "string"}}
+	seed.Job.Interface.Settings = []objects.Setting{{Name: "VERBOSE"}, {Name: "COUNT"}}
+	seed.Job.Interface.Mounts = []objects.Mount{{Name: "MOUNT_BIN", Path: "/opt/bin"}}
+
+	cases := []struct {
+		fileName string
+		content  string
+		inputs   []string
+		settings []string
+		expected string
+		errorMsg string
+	}{
+		{"job.yaml", "inputs:\n  IMAGE: a,b.tif\n  BANDS: [red.tif, \"s3://bucket/nir.tif\"]\njson:\n  OPTIONS: {mode: fast, bands: [1, 2]}\n  LABEL: test\n" +
+			"settings:\n  VERBOSE: true\n  COUNT: 1000000\nmounts:\n  MOUNT_BIN: /opt/bin\n", nil, nil,
+			"[BANDS=$DIR$red.tif BANDS=s3://bucket/nir.tif IMAGE=$DIR$a,b.tif] [LABEL=test OPTIONS={\"bands\":[1,2],\"mode\":\"fast\"}] " +
+				"[COUNT=1000000 VERBOSE=true] [MOUNT_BIN=/opt/bin]", ""},
+		{"job.json", `{"inputs": {"IMAGE": "s3://bucket/a.tif"}, "json": {"OPTIONS": {"threshold": 0.5}}, "settings": {"COUNT": 1000000, "VERBOSE": true}}`,
+			[]string{"BANDS=b.tif"}, []string{"VERBOSE=false"},
+			"[IMAGE=s3://bucket/a.tif BANDS=b.tif] [OPTIONS={\"threshold\":0.5}] [COUNT=1000000 VERBOSE=false] []", ""},
+		{"job.yml", "inputs:\n  IMAGE: a.tif\n", []string{"IMAGE=/data/b.tif"}, nil, "[IMAGE=/data/b.tif] [] [] []", ""},
+		{"job.yaml", "inputs:\n  IMAGE: [a.tif, b.tif]\n  OTHER: c.tif\nsettings:\n  COUNT: [1, 2]\n  DEBUG: true\nmounts:\n  MOUNT_LIB: /opt/lib\n",
+			nil, nil, "", "The job parameters don't match the seed manifest:\n  inputs: IMAGE accepts a single file\n  inputs: OTHER is not an input file of the seed manifest\n" +
+				"  mounts: MOUNT_LIB is not a mount of the seed manifest\n  settings: COUNT must be a single value\n  settings: DEBUG is not a setting of the seed manifest"},
+		{"job.json", `{"input": {"IMAGE": "a.tif"}}`, nil, nil, "", "unknown field \"input\""},
+		{"job.yaml", "outputs:\n  IMAGE: a.tif\n", nil, nil, "", "field outputs not found"},
+		{"missing.yaml", "", nil, nil, "", "Unable to read job parameters"},
+	}
+
+	for _, c := range cases {
+		fileName := filepath.Join(dir, c.fileName)
+		os.Remove(fileName)
+		if c.content != "" {
+			ioutil.WriteFile(fileName, []byte(c.content), 0644)
+		}
+
+		inputs, json, settings, mounts, err := ApplyJobParams(&seed, fileName, c.inputs, nil, c.settings, nil)
+		if c.errorMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), c.errorMsg) {
+				t.Errorf("ApplyJobParams(%q) == %v, expected %v", c.content, err, c.errorMsg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ApplyJobParams(%q) == %v, expected %v", c.content, err, nil)
+			continue
+		}
+
+		// relative paths are resolved against the directory of the parameter file
+		expected := strings.Replace(c.expected, "$DIR$", dir+string(filepath.Separator), -1)
+		result := fmt.Sprintf("%v %v %v %v", inputs, json, settings, mounts)
+		if result != expected {
+			t.Errorf("ApplyJobParams(%q) == %v, expected %v", c.content, result, expected)
+		}
+	}
+}
@@ -1,17 +1,13 @@
 package commands
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/constants"
@@ -40,7 +36,7 @@ func DockerPublish(origImg, manifest, registry, org, username, password, jobDire
 		return "", err
 	}
 
-	if exists, err := util.ImageExists(origImg); !exists {
+	if exists, err := cliutil.Runtime().ImageExists(origImg); !exists {
 		if err != nil {
 			util.PrintUtil("%s\n", err.Error())
 			return "", err
@@ -59,13 +55,8 @@ func DockerPublish(origImg, manifest, registry, org, username, password, jobDire
 	repoTag := temp[1]
 
 	if username != "" {
-		//set config dir so we don't stomp on other users' logins with sudo
-		configDir := common_const.DockerConfigDir + time.Now().Format(time.RFC3339)
-		os.Setenv(common_const.DockerConfigKey, configDir)
-		defer util.RemoveAllFiles(configDir)
-		defer os.Unsetenv(common_const.DockerConfigKey)
-
-		err := util.Login(registry, username, password)
+		logout, err := cliutil.Runtime().Login(registry, username, password)
+		defer logout()
 		if err != nil {
 			util.PrintUtil(err.Error())
 		}
@@ -132,7 +123,8 @@ func DockerPublish(origImg, manifest, registry, org, username, password, jobDire
 			}
 		}
 
-		version := objects.SeedFromImageLabel(origImg).SeedVersion
+		imageSeed, _ := cliutil.SeedFromImageLabel(origImg)
+		version := imageSeed.SeedVersion
 		ValidateSeedFile(false, "", version, seedFileName, common_const.SchemaManifest)
 		seed := objects.SeedFromManifestFile(seedFileName)
 
@@ -220,32 +212,18 @@ func DockerPublish(origImg, manifest, registry, org, username, password, jobDire
 
 		// Build Docker image
 		util.PrintUtil("INFO: Building %s\n", img)
-		var buildArgs, dockerCommand = cliutil.DockerCommandArgsInit()
-		buildArgs = append(buildArgs, "build", "-t", img, jobDirectory)
-		if util.DockerVersionHasLabel() {
+		opts := cliutil.BuildOptions{ContextDir: jobDirectory, Tag: img}
+		if cliutil.Runtime().SupportsLabels() {
 			// Set the seed.manifest.json contents as an image label
-			label := "com.ngageoint.seed.manifest=" + objects.GetManifestLabel(seedFileName)
-			buildArgs = append(buildArgs, "--label", label)
-		}
-		util.PrintUtil("INFO: Running Docker command:\n%s %s\n", dockerCommand, strings.Join(buildArgs, " "))
-		rebuildCmd := exec.Command(dockerCommand, buildArgs...)
-		var errs bytes.Buffer
-		if util.StdErr != nil {
-			rebuildCmd.Stderr = io.MultiWriter(util.StdErr, &errs)
-		} else {
-			rebuildCmd.Stderr = &errs
+			opts.Labels = map[string]string{cliutil.ManifestLabel: objects.GetManifestLabel(seedFileName)}
 		}
-		rebuildCmd.Stdout = util.StdErr
 
 		// Run docker build
-		rebuildCmd.Run()
-
-		// check for errors on stderr
-		if errs.String() != "" {
+		if err = cliutil.Runtime().Build(opts); err != nil {
 			util.PrintUtil("ERROR: Error re-building image '%s':\n%s\n",
-				img, errs.String())
+				img, err.Error())
 			util.PrintUtil("Exiting seed...\n")
-			return "", errors.New(errs.String())
+			return "", err
 		}
 
 		// Set final image name to tag + image
@@ -253,17 +231,17 @@ func DockerPublish(origImg, manifest, registry, org, username, password, jobDire
 		img = tag + img
 	}
 
-	err := util.Tag(origImg, img)
+	err := cliutil.Runtime().Tag(origImg, img)
 	if err != nil {
 		return img, err
 	}
 
-	err = util.Push(img)
+	err = cliutil.Runtime().Push(img)
 	if err != nil {
 		return img, err
 	}
 
-	err = util.RemoveImage(img)
+	err = cliutil.Runtime().RemoveImage(img)
 	if err != nil {
 		return img, err
 	}
@@ -290,6 +268,8 @@ func PrintPublishUsage() {
 		constants.ShortPassFlag, constants.PassFlag)
 	util.PrintUtil("  -%s\t\t Overwrite remote image if publish conflict found\n",
 		constants.ForcePublishFlag)
+	util.PrintUtil("  -%s\t Container runtime to use: docker or podman (default is $%s or docker).\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
 
 	util.PrintUtil("\nConflict Options:\n")
 	util.PrintUtil("If the force flag (-f) is not set, the following options specify how a publish conflict is handled:\n")
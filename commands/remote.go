@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// stager keeps the remote inputs downloaded by this process so the rows of a batch that
+// share an input only download it once
+var stager = remote.NewStager(remote.DefaultCacheDir())
+
+//StageInputs downloads the input files and json input files given as URIs into the seed
+// cache and replaces them with the paths of the local copies. Json inputs are only read from
+// a URI given as a file (@URI); other json values are passed as given
+func StageInputs(inputs, json []string) ([]string, []string, error) {
+	return stageInputs(inputs, json, fetch)
+}
+
+//ResolveDryRunInputs replaces the input files and json input files given as file URIs with
+// their paths. A dry run doesn't download anything, so inputs given as other URIs are an error
+func ResolveDryRunInputs(inputs, json []string) ([]string, []string, error) {
+	return stageInputs(inputs, json, func(uri string) (string, error) {
+		if !strings.HasPrefix(strings.ToLower(uri), "file://") {
+			return "", fmt.Errorf("ERROR: A dry run doesn't download inputs, so %s can't be used. "+
+				"Download it first and give its local path.\n", uri)
+		}
+		return stager.Stage(uri)
+	})
+}
+
+//CheckDryRunOutputDir returns an error if the output directory of a dry run is a URI, as a dry
+// run doesn't create the directory in the seed cache the outputs would be uploaded from
+func CheckDryRunOutputDir(outputDir string) error {
+	if remote.IsURI(outputDir) {
+		return fmt.Errorf("ERROR: A dry run doesn't upload outputs, so the output directory can't be %s. "+
+			"Give a local directory and upload its contents once the job has run.\n", outputDir)
+	}
+	return nil
+}
+
+// fetch downloads uri into the seed cache
+func fetch(uri string) (string, error) {
+	util.PrintUtil("INFO: Fetching %s\n", uri)
+	return stager.Stage(uri)
+}
+
+// stageInputs replaces the URI values of the inputs and the json inputs given as @URI with the
+// local paths returned by stage
+func stageInputs(inputs, json []string, stage func(uri string) (string, error)) ([]string, []string, error) {
+	staged, err := stageValues(inputs, "", stage)
+	if err != nil {
+		return inputs, json, err
+	}
+	stagedJson, err := stageValues(json, constants.JsonFilePrefix, stage)
+	if err != nil {
+		return inputs, json, err
+	}
+	return staged, stagedJson, nil
+}
+
+// stageValues stages the URI values of KEY=VALUE arguments. Only values starting with prefix
+// are staged, and the prefix is kept
+func stageValues(values []string, prefix string, stage func(uri string) (string, error)) ([]string, error) {
+	var staged []string
+	for _, v := range values {
+		x := strings.SplitN(v, "=", 2)
+		if len(x) != 2 || !strings.HasPrefix(x[1], prefix) || !remote.IsURI(x[1][len(prefix):]) {
+			staged = append(staged, v)
+			continue
+		}
+
+		local, err := stage(x[1][len(prefix):])
+		if err != nil {
+			return values, err
+		}
+		staged = append(staged, x[0]+"="+prefix+local)
+	}
+	return staged, nil
+}
+
+//StageOutputDir returns the path of a new directory in the seed cache to write the outputs
+// of a job to before they are uploaded. The directory is created by SetOutputDir
+func StageOutputDir(imageName string) (string, error) {
+	parent := filepath.Join(stager.Dir, "outputs")
+	if err := os.MkdirAll(parent, os.ModePerm); err != nil {
+		return "", fmt.Errorf("ERROR: Unable to create output directory %s: %s\n", parent, err.Error())
+	}
+	name := fmt.Sprintf("output-%s-%s-%d", imageName, time.Now().Format("20060102_150405.000000"), nextRun())
+	return filepath.Join(parent, strings.NewReplacer(":", "_", "/", "_").Replace(name)), nil
+}
+
+//UploadOutputs uploads the contents of the output directory to the URI
+func UploadOutputs(outDir, uri string) error {
+	util.PrintUtil("INFO: Uploading %s to %s\n", outDir, uri)
+	uploaded, err := remote.Upload(outDir, uri)
+	if err != nil {
+		return err
+	}
+	util.PrintUtil("INFO: Uploaded %d files to %s\n", len(uploaded), uri)
+	return nil
+}
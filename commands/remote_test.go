@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/remote"
+)
+
+func TestStageInputs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "content of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	cacheDir, _ := ioutil.TempDir("", "seed-cache-")
+	defer os.RemoveAll(cacheDir)
+	saved := stager
+	stager = remote.NewStager(cacheDir)
+	defer func() { stager = saved }()
+
+	cases := []struct {
+		inputs   []string
+		json     []string
+		expected string
+		success  bool
+	}{
+		{[]string{"INPUT_FILE=" + server.URL + "/inputs.txt", "LOCAL=/data/inputs.txt"},
+			[]string{"CONFIG=@" + server.URL + "/config.json", "URL=" + server.URL + "/page"},
+			"[INPUT_FILE=$CACHE$/inputs.txt LOCAL=/data/inputs.txt] [CONFIG=@$CACHE$/config.json URL=" + server.URL + "/page]", true},
+		{[]string{"INPUT_FILE=file:///data/inputs.txt"}, nil, "[INPUT_FILE=" + filepath.FromSlash("/data/inputs.txt") + "] []", true},
+		{[]string{"INPUT_FILE=" + server.URL + "/missing.txt"}, nil, "", false},
+	}
+
+	for _, c := range cases {
+		inputs, json, err := StageInputs(c.inputs, c.json)
+		if c.success != (err == nil) {
+			t.Errorf("StageInputs(%q, %q) == %v, expected success %v", c.inputs, c.json, err, c.success)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		// staged files are named after the URI path in a directory of the cache
+		result := fmt.Sprintf("%v %v", inputs, json)
+		for _, v := range append(inputs, json...) {
+			value := strings.TrimPrefix(strings.SplitN(v, "=", 2)[1], "@")
+			if strings.HasPrefix(value, cacheDir) {
+				data, _ := ioutil.ReadFile(value)
+				if string(data) != "content of /"+filepath.Base(value) {
+					t.Errorf("StageInputs(%q, %q) wrote %q to %s", c.inputs, c.json, string(data), value)
+				}
+				result = strings.Replace(result, filepath.Dir(value), "$CACHE$", 1)
+			}
+		}
+		if result != c.expected {
+			t.Errorf("StageInputs(%q, %q) == %v, expected %v", c.inputs, c.json, result, c.expected)
+		}
+	}
+}
+
+func TestResolveDryRunInputs(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "seed-cache-")
+	defer os.RemoveAll(cacheDir)
+	saved := stager
+	stager = remote.NewStager(cacheDir)
+	defer func() { stager = saved }()
+
+	cases := []struct {
+		inputs   []string
+		json     []string
+		expected string
+		success  bool
+	}{
+		{[]string{"INPUT_FILE=file:///data/inputs.txt", "LOCAL=/data/inputs.txt"}, []string{"URL=http://example.com/page"},
+			"[INPUT_FILE=" + filepath.FromSlash("/data/inputs.txt") + " LOCAL=/data/inputs.txt] [URL=http://example.com/page]", true},
+		{[]string{"INPUT_FILE=http://example.com/inputs.txt"}, nil, "", false},
+		{nil, []string{"CONFIG=@s3://bucket/config.json"}, "", false},
+	}
+
+	for _, c := range cases {
+		inputs, json, err := ResolveDryRunInputs(c.inputs, c.json)
+		if c.success != (err == nil) {
+			t.Errorf("ResolveDryRunInputs(%q, %q) == %v, expected success %v", c.inputs, c.json, err, c.success)
+		}
+		if result := fmt.Sprintf("%v %v", inputs, json); err == nil && result != c.expected {
+			t.Errorf("ResolveDryRunInputs(%q, %q) == %v, expected %v", c.inputs, c.json, result, c.expected)
+		}
+	}
+	if files, _ := ioutil.ReadDir(cacheDir); len(files) != 0 {
+		t.Errorf("ResolveDryRunInputs wrote %d files to the cache, expected none", len(files))
+	}
+
+	if err := CheckDryRunOutputDir("s3://bucket/outputs"); err == nil {
+		t.Errorf("CheckDryRunOutputDir(%q) == nil, expected an error", "s3://bucket/outputs")
+	}
+	if err := CheckDryRunOutputDir("outputs"); err != nil {
+		t.Errorf("CheckDryRunOutputDir(%q) == %v, expected nil", "outputs", err)
+	}
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+// Status values recorded in the run report
+const (
+	RunStatusSuccess       = "SUCCESS"
+	RunStatusFailed        = "FAILED"
+	RunStatusTimeout       = "TIMEOUT"
+	RunStatusInvalidOutput = "INVALID_OUTPUT"
+	RunStatusDryRun        = "DRY_RUN"
+	RunStatusCancelled     = "CANCELLED"
+)
+
+// Severity values of output validation findings
+const (
+	FindingError   = "ERROR"
+	FindingWarning = "WARNING"
+)
+
+//RunReport records the details and results of a single run of a seed image. It is
+// written to the job output directory as seed.run.json
+type RunReport struct {
+	Image         string            `json:"image"`
+	DockerCommand string            `json:"dockerCommand"`
+	DockerArgs    []string          `json:"dockerArgs"`
+	OutputDir     string            `json:"outputDir"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	Status        string            `json:"status"`
+	ExitCode      int               `json:"exitCode"`
+	Error         *objects.ErrorMap `json:"error,omitempty"`
+	Logs          []string          `json:"logs,omitempty"`
+	KeptContainer string            `json:"keptContainer,omitempty"`
+	UploadedTo    string            `json:"uploadedTo,omitempty"`
+	Outputs       *OutputResults    `json:"outputs,omitempty"`
+}
+
+//OutputResults holds the results of validating the job output directory
+type OutputResults struct {
+	OutputDir    string             `json:"outputDir"`
+	Valid        bool               `json:"valid"`
+	DiskUsageMiB float64            `json:"diskUsageMiB"`
+	DiskLimitMiB float64            `json:"diskLimitMiB,omitempty"`
+	Files        []OutputFileResult `json:"files,omitempty"`
+	JSON         *OutputJSONResult  `json:"json,omitempty"`
+	Findings     []OutputFinding    `json:"findings,omitempty"`
+}
+
+//OutputFinding describes a problem found while validating the job output. Findings
+// with ERROR severity fail the run in strict mode
+type OutputFinding struct {
+	Severity string `json:"severity"`
+	Output   string `json:"output,omitempty"`
+	File     string `json:"file,omitempty"`
+	Message  string `json:"message"`
+}
+
+//AddFinding records a validation finding, marking the results invalid if it is an error
+func (r *OutputResults) AddFinding(severity, output, file, message string) {
+	r.Findings = append(r.Findings, OutputFinding{severity, output, file, message})
+	if severity == FindingError {
+		r.Valid = false
+	}
+}
+
+//Errors returns the findings with ERROR severity
+func (r *OutputResults) Errors() []OutputFinding {
+	var errs []OutputFinding
+	for _, f := range r.Findings {
+		if f.Severity == FindingError {
+			errs = append(errs, f)
+		}
+	}
+	return errs
+}
+
+//ValidationError returns an error listing the ERROR findings, or nil if there are none
+func (r *OutputResults) ValidationError() error {
+	errs := r.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("ERROR: Job output failed validation:\n")
+	for _, f := range errs {
+		buffer.WriteString("  " + f.Message + "\n")
+	}
+	return errors.New(buffer.String())
+}
+
+//OutputFileResult holds the files matched for a job.interface.outputs.files entry
+type OutputFileResult struct {
+	Name     string           `json:"name"`
+	Pattern  string           `json:"pattern"`
+	Required bool             `json:"required"`
+	Multiple bool             `json:"multiple"`
+	Count    int              `json:"count"`
+	Matches  []string         `json:"matches"`
+	Metadata []MetadataResult `json:"metadata,omitempty"`
+}
+
+//MetadataResult holds the result of validating a side-car metadata file
+type MetadataResult struct {
+	File  string `json:"file"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+//OutputJSONResult holds the result of validating the results manifest (seed.outputs.json)
+// against job.interface.outputs.json
+type OutputJSONResult struct {
+	File   string   `json:"file"`
+	Found  bool     `json:"found"`
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+//WriteRunReport writes the run report to seed.run.json within the given output directory
+func WriteRunReport(outDir string, report *RunReport) error {
+	if outDir == "" {
+		return nil
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		util.PrintUtil("ERROR: Error creating run report. %s\n", err.Error())
+		return err
+	}
+
+	reportFile := filepath.Join(outDir, constants.RunReportFileName)
+	err = ioutil.WriteFile(reportFile, reportJSON, 0644)
+	if err != nil {
+		util.PrintUtil("ERROR: Error writing run report %s. %s\n", reportFile, err.Error())
+		return err
+	}
+
+	util.PrintUtil("INFO: Run report written to %s\n", reportFile)
+	return nil
+}
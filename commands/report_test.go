@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+func init() {
+	util.InitPrinter(util.Quiet, nil, nil)
+}
+
+func TestWriteRunReport(t *testing.T) {
+	outDir, _ := ioutil.TempDir("", "seed-report-")
+	defer os.RemoveAll(outDir)
+
+	start := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := RunReport{
+		Image:         "addition-job-0.0.1-seed:1.0.0",
+		DockerCommand: "docker",
+		DockerArgs:    []string{"run", "--rm", "addition-job-0.0.1-seed:1.0.0"},
+		Start:         start,
+		End:           start.Add(time.Minute),
+		Status:        RunStatusFailed,
+		ExitCode:      1,
+		Error:         &objects.ErrorMap{Code: 1, Name: "data-error", Category: "data"},
+		Outputs: &OutputResults{OutputDir: outDir,
+			Files: []OutputFileResult{{Name: "OUTPUT_FILE", Count: 0}}},
+	}
+
+	if err := WriteRunReport(outDir, &report); err != nil {
+		t.Fatalf("WriteRunReport returned an error: %v", err)
+	}
+
+	bytes, err := ioutil.ReadFile(filepath.Join(outDir, constants.RunReportFileName))
+	if err != nil {
+		t.Fatalf("Error reading run report: %v", err)
+	}
+
+	var read RunReport
+	if err := json.Unmarshal(bytes, &read); err != nil {
+		t.Fatalf("Error parsing run report: %v", err)
+	}
+
+	if read.Image != report.Image || read.ExitCode != 1 || read.Status != RunStatusFailed {
+		t.Errorf("WriteRunReport wrote %v, expected %v", read, report)
+	}
+	if read.Error == nil || read.Error.Name != "data-error" {
+		t.Errorf("WriteRunReport wrote error %v, expected %v", read.Error, report.Error)
+	}
+	if !read.End.Equal(report.End) {
+		t.Errorf("WriteRunReport wrote end time %v, expected %v", read.End, report.End)
+	}
+	if read.Outputs == nil || len(read.Outputs.Files) != 1 {
+		t.Errorf("WriteRunReport wrote outputs %v, expected %v", read.Outputs, report.Outputs)
+	}
+}
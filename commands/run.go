@@ -14,14 +14,16 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
 	"github.com/ngageoint/seed-cli/streampainter"
 	common_const "github.com/ngageoint/seed-common/constants"
 	"github.com/ngageoint/seed-common/objects"
@@ -29,154 +31,380 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
+//ErrJobTimeout is returned by DockerRun when the job exceeds its timeout
+var ErrJobTimeout = errors.New("ERROR: Job exceeded its timeout and was stopped.")
+
+//ErrCancelled is returned by DockerRun and BatchRun when seed is interrupted
+var ErrCancelled = errors.New("ERROR: The run was cancelled.")
+
+//RunOptions describes a run of a seed image by DockerRun
+type RunOptions struct {
+	// ImageName is the image to run. If empty, the image is named after the seed manifest file
+	// Manifest
+	ImageName string
+	Manifest  string
+	// OutputDir is the job output directory. The outputs are uploaded to an output directory
+	// given as a URI once they have been checked
+	OutputDir string
+	// MetadataSchema overrides the built in schema the side-car metadata files are validated with
+	MetadataSchema string
+	Inputs         []string
+	Json           []string
+	Settings       []string
+	Mounts         []string
+	// Params names a job parameter file whose inputs, json inputs, settings and mounts are used
+	// for those not given by Inputs, Json, Settings and Mounts
+	Params string
+	// Remove removes the container once it exits (docker run --rm)
+	Remove bool
+	// Quiet suppresses the output of seed
+	Quiet bool
+	// Timeout is the number of seconds the job may run before it is stopped. If it isn't
+	// greater than zero, the job.timeout value of the seed manifest is used
+	Timeout int
+	// Strict fails the run on output validation errors: missing required outputs, invalid
+	// side-car metadata or an invalid seed.outputs.json
+	Strict bool
+	// StrictMediaTypes fails the run before the container is started when input files don't
+	// match the mediaTypes declared in the manifest. Otherwise a warning is printed
+	StrictMediaTypes bool
+	// Format is the format of the run report. For the json and yaml formats it is also written
+	// to stdout once the container has run
+	Format string
+	// DryRun resolves and prints the docker command without starting a container. If Script
+	// is given, the command is appended to it instead and DryRun is implied
+	DryRun bool
+	Script io.Writer
+	// Shell starts an interactive shell in the job container instead of the job command, with
+	// the same inputs, settings, mounts and resources
+	Shell bool
+	// KeepOnFailure keeps the container of a failed run, even with Remove, and prints the
+	// commands to inspect it
+	KeepOnFailure bool
+	// Force runs a job the host doesn't have the resources for, as compared with the capacity of
+	// the host and the free space of the output directory, with a warning instead of refusing it
+	Force bool
+	// RunAs is the user the container runs as, as described by RunUser
+	RunAs string
+	// logStderrOnly writes the stderr of the container only to the logs in the output directory,
+	// so the rows of a batch run at the same time don't write over each other and the progress bar
+	logStderrOnly bool
+}
+
 //DockerRun Runs image described by Seed spec
-func DockerRun(imageName, manifest, outputDir, metadataSchema string, inputs, json, settings, mounts []string, rmDir, quiet bool) (int, error) {
+// If seed is interrupted, the container is stopped and removed, the run report is written with
+// the CANCELLED status and ErrCancelled is returned
+func DockerRun(opts RunOptions) (int, error) {
 	util.InitPrinter(util.PrintErr, os.Stderr, os.Stderr)
-	if quiet {
+	if opts.Quiet {
 		util.InitPrinter(util.Quiet, nil, nil)
 	}
 
-	if imageName == "" {
-		util.PrintUtil("INFO: Image name not specified. Attempting to use manifest: %v\n", manifest)
-		temp, err := objects.GetImageNameFromManifest(manifest, "")
+	return dockerRun(opts, nil)
+}
+
+// dockerRun runs the image as described by DockerRun using the printer that is already set up,
+// so the rows of a batch can be run at the same time. If report isn't nil it is set to the run
+// report once the container is started
+func dockerRun(opts RunOptions, report *RunReport) (int, error) {
+	opts.DryRun = opts.DryRun || opts.Script != nil
+	defer cliutil.WatchInterrupts()()
+
+	if opts.ImageName == "" {
+		util.PrintUtil("INFO: Image name not specified. Attempting to use manifest: %v\n", opts.Manifest)
+		temp, err := objects.GetImageNameFromManifest(opts.Manifest, "")
 		if err != nil {
 			return 0, err
 		}
-		imageName = temp
+		opts.ImageName = temp
 	}
 
-	if imageName == "" {
+	if opts.ImageName == "" {
 		return 0, errors.New("ERROR: No input image specified.")
 	}
 
-	if exists, err := util.ImageExists(imageName); !exists {
-		msg := fmt.Sprintf("Unable to find image: %s. Did you specify a valid tag?", imageName)
+	if exists, err := cliutil.Runtime().ImageExists(opts.ImageName); !exists {
+		msg := fmt.Sprintf("Unable to find image: %s. Did you specify a valid tag?", opts.ImageName)
 		util.PrintUtil("%s\n", msg)
 		return 0, err
 	}
 
 	// Parse seed information off of the label
-	seed := objects.SeedFromImageLabel(imageName)
+	seed, err := cliutil.SeedFromImageLabel(opts.ImageName)
+	if err != nil {
+		util.PrintUtil("%s", err.Error())
+		return 0, err
+	}
+
+	if opts.Params != "" {
+		opts.Inputs, opts.Json, opts.Settings, opts.Mounts, err = ApplyJobParams(&seed, opts.Params, opts.Inputs, opts.Json, opts.Settings, opts.Mounts)
+		if err != nil {
+			util.PrintUtil("%s", err.Error())
+			return -1, err
+		}
+	}
+
+	// inputs given as URIs are downloaded to the seed cache and used from there. A dry run
+	// doesn't download inputs or create a directory in the cache for the outputs
+	if opts.DryRun {
+		if err = CheckDryRunOutputDir(opts.OutputDir); err == nil {
+			opts.Inputs, opts.Json, err = ResolveDryRunInputs(opts.Inputs, opts.Json)
+		}
+	} else {
+		opts.Inputs, opts.Json, err = StageInputs(opts.Inputs, opts.Json)
+	}
+	if cliutil.Interrupted().Err() != nil {
+		return constants.CancelledExitCode, ErrCancelled
+	} else if err != nil {
+		util.PrintUtil("%s", err.Error())
+		return -1, err
+	}
+
+	// the outputs for a URI are written to the seed cache and uploaded once they have been checked
+	outputURI := ""
+	if remote.IsURI(opts.OutputDir) {
+		outputURI = opts.OutputDir
+		if opts.OutputDir, err = StageOutputDir(opts.ImageName); err != nil {
+			util.PrintUtil("%s", err.Error())
+			return -1, err
+		}
+	}
 
-	// build docker run command
-	var dockerArgs, dockerCommand = cliutil.DockerCommandArgsInit()
-	dockerArgs = append(dockerArgs, "run")
-	if rmDir {
-		dockerArgs = append(dockerArgs, "--rm")
+	// describe the container to run. A container that may be kept is removed by seed once it
+	// has succeeded, and is named so it can be stopped if the job times out
+	spec := cliutil.RunSpec{Name: ContainerName(&seed), Remove: opts.Remove && !opts.KeepOnFailure, Image: opts.ImageName}
+	if opts.Shell {
+		spec.Interactive, spec.Tty = true, streampainter.IsTerminal(os.Stdin)
+		spec.Entrypoint = constants.DebugShell
+	}
+
+	if spec.User, err = RunUser(&seed, opts.RunAs); err != nil {
+		util.PrintUtil("%s", err.Error())
+		return -1, err
 	}
 
-	var mountsArgs []string
-	var envArgs []string
-	var resourceArgs []string
 	var inputSize float64
 	var outputSize float64
 
 	// expand INPUT_FILEs to specified Inputs files
 	if seed.Job.Interface.Inputs.Files != nil {
-		inMounts, size, temp, err := DefineInputs(&seed, inputs)
-		for _, v := range temp {
-			defer util.RemoveAllFiles(v)
-		}
+		size, err := DefineInputs(&seed, opts.Inputs, &spec)
 		if err != nil {
 			util.PrintUtil("ERROR: Error occurred processing inputs arguments.\n%s", err.Error())
 			return -1, err
-		} else if inMounts != nil {
-			mountsArgs = append(mountsArgs, inMounts...)
-			inputSize = size
+		}
+		inputSize = size
+
+		mismatches, err := CheckInputMediaTypes(&seed, opts.Inputs)
+		if err != nil {
+			util.PrintUtil("%s\n", err.Error())
+			return -1, err
+		}
+		for _, m := range mismatches {
+			if opts.StrictMediaTypes {
+				util.PrintUtil("ERROR: %s\n", m.String())
+			} else {
+				util.PrintUtil("WARNING: %s\n", m.String())
+			}
+		}
+		if len(mismatches) > 0 && opts.StrictMediaTypes {
+			return -1, errors.New("ERROR: Input files do not match the media types declared in the seed manifest.")
 		}
 	}
 
-	// add -e args for input json
+	// set the input json variables
 	if seed.Job.Interface.Inputs.Json != nil {
-		inJson, err := DefineInputJson(&seed, json)
-		if err != nil {
+		if err := DefineInputJson(&seed, opts.Json, &spec); err != nil {
 			util.PrintUtil("ERROR: Error occurred processing json arguments.\n%s", err.Error())
 			return -1, err
-		} else if inJson != nil {
-			envArgs = append(envArgs, inJson...)
 		}
 	}
 
 	if len(seed.Job.Resources.Scalar) > 0 {
-		inResources, diskSize, err := DefineResources(&seed, inputSize)
+		diskSize, err := DefineResources(&seed, inputSize, &spec)
 		if err != nil {
 			util.PrintUtil("ERROR: Error occurred processing resources\n%s", err.Error())
 			return -1, err
-		} else if inResources != nil {
-			resourceArgs = append(resourceArgs, inResources...)
-			outputSize = diskSize
 		}
+		outputSize = diskSize
 	}
 
 	// mount the JOB_OUTPUT_DIR (outDir flag)
+	// a dry run doesn't leave behind an output directory it had to create
 	var outDir string
-	outDir = SetOutputDir(imageName, &seed, outputDir)
+	_, statErr := os.Stat(util.GetFullPath(opts.OutputDir, ""))
+	outDir = SetOutputDir(opts.ImageName, &seed, opts.OutputDir)
+	if opts.DryRun && (opts.OutputDir == "" || statErr != nil || outDir != util.GetFullPath(opts.OutputDir, "")) {
+		defer os.Remove(outDir)
+	}
 	if outDir != "" {
-		mountsArgs = append(mountsArgs, "-v")
-		mountsArgs = append(mountsArgs, outDir+":"+outDir)
-		mountsArgs = append(mountsArgs, "-e")
-		mountsArgs = append(mountsArgs, "OUTPUT_DIR="+outDir)
+		spec.AddBind(outDir, outDir, "")
+		spec.AddEnv("OUTPUT_DIR", outDir)
 	} else {
 		util.PrintUtil("ERROR: Empty output directory string!\n")
 	}
 
+	// a dry run only warns as the job may be run on another host
+	if err := CapacityError(CheckHostCapacity(&seed, inputSize, outDir)); err != nil {
+		if !opts.Force && !opts.DryRun {
+			return -1, fmt.Errorf("%sUse -%s to run the job anyway.\n", err.Error(), constants.ForceRunFlag)
+		}
+		util.PrintUtil("WARNING: %s", strings.TrimPrefix(err.Error(), "ERROR: "))
+	}
+
 	// Settings
+	var secrets map[string]string
 	if seed.Job.Interface.Settings != nil {
-		inSettings, err := DefineSettings(&seed, settings)
+		inSecrets, err := DefineSettings(&seed, opts.Settings, &spec)
 		if err != nil {
 			util.PrintUtil("ERROR: Error occurred processing settings arguments.\n%s", err.Error())
 			return -1, err
-		} else if inSettings != nil {
-			envArgs = append(envArgs, inSettings...)
 		}
+		secrets = inSecrets
+	}
+
+	// Secret settings are passed through a temporary env-file so they don't appear
+	// in the docker command line. The file is removed once the run completes
+	// A dry run passes them by name so their values are taken from the caller's environment
+	if len(secrets) > 0 && opts.DryRun {
+		spec.Env = append(spec.Env, sortedKeys(secrets)...)
+	} else if len(secrets) > 0 {
+		envFile, err := WriteEnvFile(secrets)
+		if envFile != "" {
+			defer os.Remove(envFile)
+			defer cliutil.OnInterrupt(func() { os.Remove(envFile) })()
+		}
+		if err != nil {
+			util.PrintUtil("ERROR: Error occurred writing secret settings.\n%s\n", err.Error())
+			return -1, err
+		}
+		spec.EnvFile = envFile
 	}
 
 	// Additional Mounts defined in seed.json
 	if seed.Job.Interface.Mounts != nil {
-		inMounts, err := DefineMounts(&seed, mounts)
-		if err != nil {
+		if err := DefineMounts(&seed, opts.Mounts, &spec); err != nil {
 			util.PrintUtil("ERROR: Error occurred processing mount arguments.\n%s", err.Error())
 			return -1, err
-		} else if inMounts != nil {
-			mountsArgs = append(mountsArgs, inMounts...)
 		}
 	}
 
-	// Build Docker command arguments:
-	// 		run
-	//		-rm if specified
-	//		env injection
-	// 		all mounts
-	//		image name
-	//		Job.Interface.Command
-	dockerArgs = append(dockerArgs, mountsArgs...)
-	dockerArgs = append(dockerArgs, envArgs...)
-	dockerArgs = append(dockerArgs, resourceArgs...)
-	dockerArgs = append(dockerArgs, imageName)
+	// Parse out command arguments from seed.Job.Interface.Command, substituting the inputs,
+	// settings and OUTPUT_DIR. The debug shell is started without them
+	if err := CheckCommandSecrets(seed.Job.Interface.Command, secrets); err != nil {
+		util.PrintUtil("%s", err.Error())
+		return -1, err
+	}
+	args, err := ExpandCommand(seed.Job.Interface.Command, CommandVars(&seed, spec.Env))
+	if err != nil {
+		util.PrintUtil("%s", err.Error())
+		return -1, err
+	}
+	if len(args) > 0 {
+		seed.Job.Interface.Command = ShellJoin(args[0], args[1:])
+	}
+	if !opts.Shell {
+		spec.Cmd = args
+	}
+
+	// the docker command is printed, written to dry run scripts and run by the shell
+	initArgs, dockerCommand := cliutil.Runtime().CommandArgsInit()
+	dockerArgs := append(append(append([]string{}, initArgs...), "run"), spec.Args()...)
 
-	// Parse out command arguments from seed.Job.Interface.Command
-	args := strings.Split(seed.Job.Interface.Command, " ")
-	dockerArgs = append(dockerArgs, args...)
+	if opts.DryRun {
+		run := DryRunResult{Image: opts.ImageName, DockerCommand: dockerCommand, DockerArgs: dockerArgs,
+			Command: MaskSecrets(seed.Job.Interface.Command, secrets), OutputDir: outDir, Secrets: sortedKeys(secrets)}
+		return 0, PrintDryRun(&run, opts.Format, opts.Script)
+	}
 
 	// Run
-	util.PrintUtil("INFO: Running Docker command:\n%s %s\n", dockerCommand, strings.Join(dockerArgs, " "))
+	util.PrintUtil("INFO: Running Docker command:\n%s %s\n", dockerCommand,
+		MaskSecrets(strings.Join(dockerArgs, " "), secrets))
+
+	if opts.Shell {
+		util.PrintUtil("INFO: Starting %s in the job container. The job command is:\n%s\n", constants.DebugShell,
+			MaskSecrets(seed.Job.Interface.Command, secrets))
+		return RunShell(dockerCommand, dockerArgs)
+	}
 
 	// Run Docker command and capture output
-	dockerRun := exec.Command(dockerCommand, dockerArgs...)
 	var errs bytes.Buffer
-	dockerRun.Stderr = io.MultiWriter(&errs, streampainter.NewStreamPainter(color.FgRed))
-	dockerRun.Stdout = util.StdOut
+	var stderr io.Writer = &errs
+	if !opts.logStderrOnly {
+		stderr = io.MultiWriter(&errs, streampainter.NewStreamPainter(color.FgRed))
+	}
 
 	// Run docker run
+	opts.Timeout = JobTimeout(&seed, opts.Timeout)
+	if opts.Timeout > 0 {
+		util.PrintUtil("INFO: Job will be stopped if it runs longer than %d seconds\n", opts.Timeout)
+	}
 	runTime := time.Now()
-	err := dockerRun.Run()
-	util.TimeTrack(runTime, "INFO: "+imageName+" run")
+
+	// record the run in seed.run.json within the output directory however the run ends
+	if report == nil {
+		report = &RunReport{}
+	}
+	*report = RunReport{Image: opts.ImageName, DockerCommand: dockerCommand, OutputDir: outDir, Start: runTime}
+	for _, arg := range dockerArgs {
+		report.DockerArgs = append(report.DockerArgs, MaskSecrets(arg, secrets))
+	}
+	uploaded := false
+	defer func() {
+		if uploaded {
+			os.RemoveAll(outDir)
+		} else {
+			WriteRunReport(outDir, report)
+		}
+	}()
+	defer WriteResult(opts.Format, report)
+
+	// save the container output in the output directory while streaming it to the terminal
+	stdout := util.StdOut
+	logs, logErr := OpenRunLogs(outDir, stdout, stderr, secrets)
+	if logErr != nil {
+		util.PrintUtil("WARNING: Container output will not be saved. %s", logErr.Error())
+	} else {
+		stdout, stderr = logs.Stdout, logs.Stderr
+		report.Logs = logs.Files
+	}
+
+	err = RunContainer(spec, stdout, stderr, opts.Timeout, opts.KeepOnFailure)
+	if logs != nil {
+		logs.Close()
+	}
+	report.End = time.Now()
+	if opts.KeepOnFailure && err != nil && err != ErrCancelled {
+		report.KeptContainer = spec.Name
+		PrintKeptContainer(strings.Join(append([]string{dockerCommand}, initArgs...), " "), spec.Name)
+	} else if opts.KeepOnFailure && opts.Remove {
+		cliutil.Runtime().RemoveContainer(spec.Name)
+	}
+	util.TimeTrack(runTime, "INFO: "+opts.ImageName+" run")
 	exitCode := 0
-	if err != nil {
-		exitError, ok := err.(*exec.ExitError)
+	report.Status = RunStatusSuccess
+	stopped := "stopped and removed"
+	if report.KeptContainer != "" {
+		stopped = "stopped and kept"
+	}
+	if err == ErrJobTimeout {
+		util.PrintUtil("TIMEOUT: %s exceeded the job timeout of %d seconds. Container %s was %s.\n",
+			opts.ImageName, opts.Timeout, spec.Name, stopped)
+		report.Status = RunStatusTimeout
+		report.ExitCode = constants.TimeoutExitCode
+		return constants.TimeoutExitCode, err
+	} else if err == ErrCancelled {
+		util.PrintUtil("CANCELLED: The run of %s was interrupted. Container %s was %s.\n",
+			opts.ImageName, spec.Name, stopped)
+		report.Status = RunStatusCancelled
+		report.ExitCode = constants.CancelledExitCode
+		return constants.CancelledExitCode, err
+	} else if err != nil {
+		report.Status = RunStatusFailed
+		exitError, ok := err.(*cliutil.ExitError)
 		if ok {
-			ws := exitError.Sys().(syscall.WaitStatus)
-			exitCode = ws.ExitStatus()
+			exitCode = exitError.Code
+			report.ExitCode = exitCode
 			util.PrintUtil("Exited with error code %v\n", exitCode)
 			match := false
 			for _, e := range seed.Job.Errors {
@@ -185,6 +413,8 @@ func DockerRun(imageName, manifest, outputDir, metadataSchema string, inputs, js
 					util.PrintUtil("Description: \t %s\n", e.Description)
 					util.PrintUtil("Category: \t %s \n \n", e.Category)
 					match = true
+					matched := e
+					report.Error = &matched
 					util.PrintUtil("Exiting seed...\n")
 					return exitCode, err
 				}
@@ -200,18 +430,208 @@ func DockerRun(imageName, manifest, outputDir, metadataSchema string, inputs, js
 
 	if errs.String() != "" {
 		util.PrintUtil("stderr for '%s':\n%s\n",
-			imageName, errs.String())
+			opts.ImageName, MaskSecrets(errs.String(), secrets))
 	}
 
 	// Validate output against pattern
 	if seed.Job.Interface.Outputs.Files != nil ||
 		seed.Job.Interface.Outputs.JSON != nil {
-		CheckRunOutput(&seed, outDir, metadataSchema, outputSize)
+		outputs := CheckRunOutput(&seed, outDir, opts.MetadataSchema, outputSize)
+		report.Outputs = &outputs
+
+		if opts.Strict && err == nil {
+			if vErr := outputs.ValidationError(); vErr != nil {
+				util.PrintUtil("%s", vErr.Error())
+				report.Status = RunStatusInvalidOutput
+				err = vErr
+			}
+		}
+	}
+
+	if outputURI != "" && err == nil {
+		if report.Outputs != nil && !report.Outputs.Valid {
+			util.PrintUtil("WARNING: The outputs are not valid and were not uploaded to %s. They are kept in %s\n", outputURI, outDir)
+			return exitCode, err
+		}
+
+		// the run report is uploaded with the outputs
+		report.UploadedTo = outputURI
+		WriteRunReport(outDir, report)
+		if err = UploadOutputs(outDir, outputURI); err != nil {
+			util.PrintUtil("%sThe outputs are kept in %s\n", err.Error(), outDir)
+			report.UploadedTo = ""
+			report.Status = RunStatusFailed
+			if cliutil.Interrupted().Err() != nil {
+				report.Status = RunStatusCancelled
+				report.ExitCode = constants.CancelledExitCode
+				return constants.CancelledExitCode, ErrCancelled
+			}
+			return exitCode, err
+		}
+		uploaded = true
 	}
 
 	return exitCode, err
 }
 
+//PrintDryRun prints the resolved docker command and job command of a dry run, writes them to
+// stdout for the json and yaml formats and appends them to the script if one is given
+func PrintDryRun(run *DryRunResult, format string, script io.Writer) error {
+	util.PrintUtil("INFO: Dry run; the container was not started.\n")
+	util.PrintUtil("Job command:\n%s\n", run.Command)
+	util.PrintUtil("Docker command:\n%s\n", ShellJoin(run.DockerCommand, run.DockerArgs))
+	if len(run.Secrets) > 0 {
+		util.PrintUtil("Secret settings are read from the environment: %s\n", strings.Join(run.Secrets, ", "))
+	}
+
+	if script != nil {
+		if err := WriteRunScript(script, run); err != nil {
+			return fmt.Errorf("ERROR: Unable to write script: %s\n", err.Error())
+		}
+	}
+	return WriteResult(format, run)
+}
+
+//JobTimeout returns the number of seconds the job may run. A positive override takes
+// precedence over the job.timeout value of the seed manifest. Zero means no timeout.
+func JobTimeout(seed *objects.Seed, override int) int {
+	if override > 0 {
+		return override
+	}
+	if seed.Job.Timeout > 0 {
+		return seed.Job.Timeout
+	}
+	return 0
+}
+
+//ContainerName returns a unique container name for a run of the given seed job
+func ContainerName(seed *objects.Seed) string {
+	name := seed.Job.Name
+	if name == "" {
+		name = "job"
+	}
+	return fmt.Sprintf("seed-%s-%d-%d", name, time.Now().UnixNano(), nextRun())
+}
+
+// runCount numbers the runs started by seed so the names of their containers and staged output
+// directories are unique even when the rows of a batch start at the same time
+var runCount uint64
+
+func nextRun() uint64 {
+	return atomic.AddUint64(&runCount, 1)
+}
+
+var userPattern = regexp.MustCompile(`^[0-9]+(:[0-9]+)?$`)
+
+//RunUser returns the UID[:GID] the job container runs as, or an empty string for the user set
+// by the image. By default the container runs as the user running seed (host) so the files it
+// writes to the output directory are owned by them, unless the seed manifest has the
+// run-as-root tag. The user may also be the user set by the image (image), root or a UID[:GID]
+func RunUser(seed *objects.Seed, user string) (string, error) {
+	if user == "" && util.ContainsString(seed.Job.Tags, constants.RunAsRootTag) {
+		user = constants.RootUser
+	}
+
+	switch {
+	case user == "" || user == constants.HostUser:
+		return cliutil.Runtime().HostUser(), nil
+	case user == constants.ImageUser:
+		return "", nil
+	case user == constants.RootUser:
+		return "0:0", nil
+	case userPattern.MatchString(user):
+		return user, nil
+	}
+	return "", fmt.Errorf("ERROR: Invalid user %s. The user must be %s, %s, %s or UID[:GID].\n", user,
+		constants.HostUser, constants.ImageUser, constants.RootUser)
+}
+
+//RunContainer runs the container described by spec and waits for it to exit. If timeout is greater than zero and elapses before the container exits, the
+// container is stopped and ErrJobTimeout is returned. The stopped container is removed
+// unless keep is true. If seed is interrupted, the container is stopped and removed and
+// ErrCancelled is returned
+func RunContainer(spec cliutil.RunSpec, stdout, stderr io.Writer, timeout int, keep bool) error {
+	if cliutil.Interrupted().Err() != nil {
+		return ErrCancelled
+	}
+	containerName := spec.Name
+	// the container is removed if seed exits without waiting for it
+	defer cliutil.OnInterrupt(func() { RemoveContainer(containerName) })()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		timer = time.After(time.Duration(timeout) * time.Second)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cliutil.Runtime().Run(spec, stdout, stderr)
+	}()
+
+	select {
+	case err := <-done:
+		// the interrupt is also sent to the job by docker run, so the job may exit first
+		if err != nil && cliutil.Interrupted().Err() != nil {
+			cliutil.Runtime().RemoveContainer(containerName)
+			return ErrCancelled
+		}
+		return err
+	case <-cliutil.Interrupted().Done():
+		util.PrintUtil("INFO: Stopping container %s...\n", containerName)
+		RemoveContainer(containerName)
+		<-done
+		return ErrCancelled
+	case <-timer:
+		util.PrintUtil("INFO: Timeout of %d seconds reached. Stopping container %s...\n", timeout, containerName)
+		if keep {
+			if err := cliutil.Runtime().StopContainer(containerName); err != nil {
+				util.PrintUtil("ERROR: Error stopping container %s. %s", containerName, err.Error())
+			}
+		} else {
+			RemoveContainer(containerName)
+		}
+		<-done
+		return ErrJobTimeout
+	}
+}
+
+//RunShell runs the docker command with the terminal attached so the user can interact with
+// the container. Returns the exit code of the shell
+func RunShell(dockerCommand string, dockerArgs []string) (int, error) {
+	cmd := exec.Command(dockerCommand, dockerArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if exitError, ok := err.(*exec.ExitError); ok {
+		return exitError.ExitCode(), nil
+	} else if err != nil {
+		return -1, fmt.Errorf("ERROR: Error starting shell in the job container. %s\n", err.Error())
+	}
+	return 0, nil
+}
+
+//PrintKeptContainer prints the commands to inspect the container of a failed run. The
+// docker command includes any arguments needed to invoke the runtime, e.g. sudo docker
+func PrintKeptContainer(dockerCommand, containerName string) {
+	util.PrintUtil("INFO: Container %s was kept for debugging. To inspect it:\n", containerName)
+	util.PrintUtil("  Show its output:\t\t%s logs %s\n", dockerCommand, containerName)
+	util.PrintUtil("  Run the job again in it:\t%s start -ai %s\n", dockerCommand, containerName)
+	util.PrintUtil("  Open a shell in its filesystem:\n\t%s commit %s %s-debug && %s run -it --rm --entrypoint %s %s-debug\n",
+		dockerCommand, containerName, containerName, dockerCommand, constants.DebugShell, containerName)
+	util.PrintUtil("  Remove it:\t\t\t%s rm %s\n", dockerCommand, containerName)
+}
+
+//RemoveContainer stops and removes the named container
+func RemoveContainer(containerName string) {
+	if err := cliutil.Runtime().StopContainer(containerName); err != nil {
+		util.PrintUtil("ERROR: Error stopping container %s. %s", containerName, err.Error())
+	}
+
+	// the container may already be gone if it was started with --rm
+	cliutil.Runtime().RemoveContainer(containerName)
+}
+
 func ListDir(path string) {
 	util.PrintUtil("Listing: %s\n", path)
 	files, err := ioutil.ReadDir(path)
@@ -225,42 +645,29 @@ func ListDir(path string) {
 }
 
 //DefineInputs extracts the paths to any input data given by the 'run' command
-// flags 'inputs' and sets the path in the json object. Returns:
-// 	[]string: docker command args for input files in the format:
-//	"-v /path/to/file1:/path/to/file1 -v /path/to/file2:/path/to/file2 etc"
-func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[string]string, error) {
+// flags 'inputs'. Each input file is bind mounted into the container at the same path and
+// its variable is set to that path. Returns the total size of the input files in MiB
+// The values of a multiple input may be repeated, be glob patterns or name directories. Each
+// matching file or directory is bind mounted read-only into a directory named after the input
+// under MultipleInputsDir, which is passed to the job. A single directory is mounted as that
+// directory
+func DefineInputs(seed *objects.Seed, inputs []string, spec *cliutil.RunSpec) (float64, error) {
 	// Validate inputs given vs. inputs defined in manifest
 
-	var mountArgs []string
 	var sizeMiB float64
 
-	inMap := inputMap(inputs, true)
+	inValues := inputValues(inputs, true)
 
 	// Valid by default
 	valid := true
 	var keys []string
-	var unrequired []string
-	var tempDirectories map[string]string
-	tempDirectories = make(map[string]string)
 	for _, f := range seed.Job.Interface.Inputs.Files {
 		normalName := util.GetNormalizedVariable(f.Name)
-		if f.Multiple {
-			tempDir := "temp-" + time.Now().Format(time.RFC3339)
-			tempDir = strings.Replace(tempDir, ":", "_", -1)
-			os.Mkdir(tempDir, os.ModePerm)
-			tempDirectories[normalName] = tempDir
-			mountArgs = append(mountArgs, "-v")
-			mountArgs = append(mountArgs, util.GetFullPath(tempDir, "")+":/"+tempDir)
-			mountArgs = append(mountArgs, "-e")
-			mountArgs = append(mountArgs, normalName+"=/"+tempDir)
-
-		}
 		if f.Required == false {
-			unrequired = append(unrequired, normalName)
 			continue
 		}
 		keys = append(keys, normalName)
-		if _, prs := inMap[normalName]; !prs {
+		if _, prs := inValues[normalName]; !prs {
 			valid = false
 		}
 	}
@@ -274,97 +681,122 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 			buffer.WriteString("  " + n + "\n")
 		}
 		buffer.WriteString("\n")
-		return nil, 0.0, tempDirectories, errors.New(buffer.String())
+		return 0.0, errors.New(buffer.String())
 	}
 
-	for key, val := range inMap {
-		// Expand input VALUE
-		val = util.GetFullPath(val, "")
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		key := util.GetNormalizedVariable(f.Name)
+		values, ok := inValues[key]
+		if !ok {
+			continue
+		}
+		if !f.Multiple && len(values) > 1 {
+			return 0.0, fmt.Errorf("ERROR: Input %s accepts a single file but %d were given\n", key, len(values))
+		}
 
-		//get total size of input files in MiB
-		info, err := os.Stat(val)
-		if os.IsNotExist(err) {
-			msg := fmt.Sprintf("ERROR: Input file %s not found\n", val)
-			return nil, 0.0, tempDirectories, errors.New(msg)
-		}
-		sizeMiB += (1.0 * float64(info.Size())) / (1024.0 * 1024.0) //fileinfo's Size() returns bytes, convert to MiB
-
-		// Replace key if found in args strings
-		// Handle replacing KEY or ${KEY} or $KEY
-		value := val
-		if directory, ok := tempDirectories[key]; ok {
-			value = "/" + directory //replace with the temp directory located at the root if multiple files
-		}
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-			"${"+key+"}", value, -1)
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command, "$"+key,
-			value, -1)
-
-		var errMsg bytes.Buffer
-		for _, k := range seed.Job.Interface.Inputs.Files {
-			normalName := util.GetNormalizedVariable(k.Name)
-			if normalName == key {
-				if k.Multiple {
-					if info.IsDir() {
-						// Can't hardlink to a directory
-						// Can't symlink to an existing directory, so remove it first
-						os.Remove(tempDirectories[key])
-						if err = os.Symlink(val, tempDirectories[key]); err != nil {
-							errMsg.WriteString("ERROR: Permissions error linking to input files for input " + key + ".\n" + err.Error() + "\n")
-						}
-					} else {
-						//directory has already been added to mount args, just link file into that directory
-						if err = os.Link(val, filepath.Join(tempDirectories[key], info.Name())); err != nil && os.IsPermission(err) {
-							errMsg.WriteString("ERROR: Permissions error linking to input files for input " + key + ".\n" + err.Error() + "\n")
-						}
-					}
+		paths, err := ExpandInputPaths(values, f.Multiple)
+		if err != nil {
+			return 0.0, err
+		}
 
-				} else {
-					mountArgs = append(mountArgs, "-v")
-					mountArgs = append(mountArgs, val+":"+val)
-					mountArgs = append(mountArgs, "-e")
-					mountArgs = append(mountArgs, key+"="+val)
-				}
+		//get total size of input files in MiB
+		for _, p := range paths {
+			size, err := pathSize(p)
+			if err != nil {
+				return 0.0, fmt.Errorf("ERROR: Unable to read input file %s: %s\n", p, err.Error())
 			}
+			sizeMiB += (1.0 * float64(size)) / (1024.0 * 1024.0) //convert bytes to MiB
+		}
+
+		value := paths[0]
+		if f.Multiple {
+			value = path.Join(constants.MultipleInputsDir, key)
+			addMultipleInputBinds(spec, paths, value)
+		} else {
+			spec.AddBind(value, value, "")
+		}
+		spec.AddEnv(key, value)
+	}
+
+	return sizeMiB, nil
+}
+
+//ExpandInputPaths returns the full paths of the files or directories given for an input. If
+// multiple is true, values that don't name an existing file are expanded as glob patterns,
+// each of which must match at least one file
+func ExpandInputPaths(values []string, multiple bool) ([]string, error) {
+	var paths []string
+	for _, v := range values {
+		p := util.GetFullPath(v, "")
+		if _, err := os.Stat(p); err == nil {
+			paths = append(paths, p)
+			continue
+		} else if !multiple || !strings.ContainsAny(v, "*?[") {
+			return nil, fmt.Errorf("ERROR: Input file %s not found\n", p)
+		}
+
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: Invalid input pattern %s: %s\n", v, err.Error())
+		} else if len(matches) == 0 {
+			return nil, fmt.Errorf("ERROR: No input files match %s\n", v)
 		}
-		if errMsg.String() != "" {
-			return nil, 0.0, tempDirectories, errors.New(errMsg.String())
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// addMultipleInputBinds bind mounts the files and directories of a multiple input read-only
+// into containerDir. Files with the same name are prefixed with a number
+func addMultipleInputBinds(spec *cliutil.RunSpec, paths []string, containerDir string) {
+	if len(paths) == 1 {
+		if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+			spec.AddBind(paths[0], containerDir, "ro")
+			return
 		}
 	}
 
-	//remove unspecified unrequired inputs from cmd string
-	for _, k := range unrequired {
-		key := k
-		value := ""
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-			"${"+key+"}", value, -1)
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command, "$"+key,
-			value, -1)
+	names := map[string]bool{}
+	for _, p := range paths {
+		name := filepath.Base(p)
+		for i := 1; names[name]; i++ {
+			name = fmt.Sprintf("%d-%s", i, filepath.Base(p))
+		}
+		names[name] = true
+		spec.AddBind(p, path.Join(containerDir, name), "ro")
 	}
+}
 
-	return mountArgs, sizeMiB, tempDirectories, nil
+// pathSize returns the size in bytes of a file or of all the files in a directory
+func pathSize(p string) (int64, error) {
+	var size int64
+	err := filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
 }
 
 //DefineInputJson passes input json values from the 'run' command
 // to the image as environment variables.  simple int/string/bool/etc.
-// types are passed as single value strings. complex objects can be read
-// in from a given file which is read in and passed as an environment variable
-// with the full json appropriately escaped
-//Returns: []string: docker command args for input files in the format:
-//	"-e JSON_NAME1=value -e JSON_NAME2="{json object}" etc"
-func DefineInputJson(seed *objects.Seed, inputs []string) ([]string, error) {
+// types are passed as single value strings. complex objects can be given
+// literally or read in from a file given as @FILE, and are passed as an
+// environment variable with the full json appropriately escaped
+// Each value is checked against the type declared by the seed manifest
+func DefineInputJson(seed *objects.Seed, inputs []string, spec *cliutil.RunSpec) error {
 	inMap := inputMap(inputs, true)
 
-	var envArgs []string
-
 	// Valid by default
 	valid := true
 	var keys []string
-	var unrequired []string
 	for _, f := range seed.Job.Interface.Inputs.Json {
 		normalName := util.GetNormalizedVariable(f.Name)
 		if f.Required == false {
-			unrequired = append(unrequired, normalName)
 			continue
 		}
 		keys = append(keys, normalName)
@@ -382,48 +814,35 @@ func DefineInputJson(seed *objects.Seed, inputs []string) ([]string, error) {
 		}
 		buffer.WriteString("\n")
 		buffer.WriteString("JSON inputs should be provided in the following form: \n")
-		buffer.WriteString("seed run -j KEY1=path/to/file1 -j KEY2=path/to/file2 ...\n")
-		return nil, errors.New(buffer.String())
+		buffer.WriteString("seed run -j KEY1=VALUE1 -j KEY2=@path/to/file2 ...\n")
+		return errors.New(buffer.String())
 	}
 
+	var invalid []string
 	for _, k := range seed.Job.Interface.Inputs.Json {
-		normalName := util.GetNormalizedVariable(k.Name)
-
-		for key, val := range inMap {
-			if normalName == key {
-				value, err := ReadJsonFile(val)
-				if err != nil {
-					value = val
-				}
-
-				// Replace key if found in args strings
-				// Handle replacing KEY or ${KEY} or $KEY
-				seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-					"${"+key+"}", value, -1)
-				seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command, "$"+key,
-					value, -1)
-
-				envArgs = append(envArgs, "-e")
-				envArgs = append(envArgs, key+"="+value)
-			}
+		key := util.GetNormalizedVariable(k.Name)
+		val, ok := inMap[key]
+		if !ok {
+			continue
+		}
+		value, err := jsonInputValue(k, val)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
 		}
-	}
 
-	//remove unspecified unrequired inputs from cmd string
-	for _, k := range unrequired {
-		key := k
-		value := ""
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-			"${"+key+"}", value, -1)
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command, "$"+key,
-			value, -1)
+		spec.AddEnv(key, value)
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("ERROR: The following json inputs don't match the types declared in the seed manifest:\n  %s\n",
+			strings.Join(invalid, "\n  "))
 	}
 
-	return envArgs, nil
+	return nil
 }
 
-//SetOutputDir replaces the OUTPUT_DIR argument with the given output directory.
-// Returns output directory string
+//SetOutputDir creates the given output directory, or a time-stamped subdirectory of it if
+// it isn't empty. Returns output directory string
 func SetOutputDir(imageName string, seed *objects.Seed, outputDir string) string {
 	// #37: if -o is not specified, auto create a time-stamped subdirectory with the name of the form:
 	//		imagename-iso8601timestamp
@@ -461,15 +880,11 @@ func SetOutputDir(imageName string, seed *objects.Seed, outputDir string) string
 		os.Mkdir(outdir, os.ModePerm)
 	}
 
-	seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-		"$OUTPUT_DIR", outdir, -1)
-	seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-		"${OUTPUT_DIR}", outdir, -1)
 	return outdir
 }
 
 //DefineMounts defines any seed specified mounts.
-func DefineMounts(seed *objects.Seed, inputs []string) ([]string, error) {
+func DefineMounts(seed *objects.Seed, inputs []string, spec *cliutil.RunSpec) error {
 	inMap := inputMap(inputs, false)
 
 	// Valid by default
@@ -491,41 +906,38 @@ func DefineMounts(seed *objects.Seed, inputs []string) ([]string, error) {
 			buffer.WriteString("  " + n + "\n")
 		}
 		buffer.WriteString("\n")
-		return nil, errors.New(buffer.String())
+		return errors.New(buffer.String())
 	}
 
-	var mounts []string
-	if seed.Job.Interface.Mounts != nil {
-		for _, mount := range seed.Job.Interface.Mounts {
-			mounts = append(mounts, "-v")
-			localPath := util.GetFullPath(inMap[mount.Name], "")
-			mountPath := localPath + ":" + mount.Path
-
-			if mount.Mode != "" {
-				mountPath += ":" + mount.Mode
-			} else {
-				mountPath += ":ro"
-			}
-			mounts = append(mounts, mountPath)
+	for _, mount := range seed.Job.Interface.Mounts {
+		localPath := util.GetFullPath(inMap[mount.Name], "")
+		mode := mount.Mode
+		if mode == "" {
+			mode = "ro"
 		}
-		return mounts, nil
+		spec.AddBind(localPath, mount.Path, mode)
 	}
 
-	return mounts, nil
+	return nil
 }
 
-//DefineSettings defines any seed specified docker settings.
-// Return []string of docker command arguments in form of:
-//	"-e setting1=val1 -e setting2=val2 etc"
-func DefineSettings(seed *objects.Seed, inputs []string) ([]string, error) {
+//DefineSettings defines any seed specified docker settings as variables of the container.
+// Settings marked secret in the seed manifest are not added to the container;
+// they are returned in a map of setting name to value so they can be passed to
+// docker without appearing on the command line
+func DefineSettings(seed *objects.Seed, inputs []string, spec *cliutil.RunSpec) (map[string]string, error) {
 	inMap := inputMap(inputs, true)
 
 	// Valid by default
 	valid := true
 	var keys []string
+	secretKeys := make(map[string]bool)
 	for _, s := range seed.Job.Interface.Settings {
 		normalName := util.GetNormalizedVariable(s.Name)
 		keys = append(keys, normalName)
+		if s.Secret {
+			secretKeys[normalName] = true
+		}
 		if _, prs := inMap[normalName]; !prs {
 			valid = false
 		}
@@ -544,77 +956,123 @@ func DefineSettings(seed *objects.Seed, inputs []string) ([]string, error) {
 		return nil, errors.New(buffer.String())
 	}
 
-	var settings []string
+	secrets := make(map[string]string)
 	for _, key := range keys {
-		// Replace key if found in args strings
-		// Handle replacing KEY or ${KEY} or $KEY
 		value := inMap[key]
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-			"${"+key+"}", value, -1)
-		seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command, "$"+key,
-			value, -1)
+		if secretKeys[key] {
+			secrets[key] = value
+			continue
+		}
 
-		settings = append(settings, "-e")
-		settings = append(settings, key+"="+value)
+		spec.AddEnv(key, value)
 	}
 
-	return settings, nil
+	return secrets, nil
+}
+
+//WriteEnvFile writes the given environment variables to a temporary file readable
+// only by the current user (ioutil.TempFile creates files with mode 0600), suitable
+// for use with docker run --env-file.
+// Returns the name of the file; the caller is responsible for removing it.
+func WriteEnvFile(env map[string]string) (string, error) {
+	f, err := ioutil.TempFile("", "seed-env-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	for key, value := range env {
+		if strings.ContainsAny(value, "\r\n") {
+			return f.Name(), fmt.Errorf("ERROR: Value of secret setting %s may not contain a newline.", key)
+		}
+		if _, err = fmt.Fprintf(f, "%s=%s\n", key, value); err != nil {
+			return f.Name(), err
+		}
+	}
+
+	return f.Name(), nil
+}
+
+//MaskSecrets replaces any occurrence of the given secret values within str with ****
+func MaskSecrets(str string, secrets map[string]string) string {
+	for _, value := range secrets {
+		if value == "" {
+			continue
+		}
+		str = strings.Replace(str, value, "****", -1)
+	}
+	return str
+}
+
+// isSeedOutputFile returns true if the file is one of the logs or reports seed writes to the
+// output directory, which are never job outputs
+func isSeedOutputFile(outDir, file string) bool {
+	if filepath.Dir(file) != filepath.Clean(outDir) {
+		return false
+	}
+	switch filepath.Base(file) {
+	case constants.RunReportFileName, constants.StdoutLogFileName, constants.StderrLogFileName, constants.CombinedLogFileName:
+		return true
+	}
+	return false
 }
 
 //DefineResources defines any seed specified docker resource requirements
 //based on the seed spec and the size of the input in MiB
-// returns array of arguments to pass to docker to restrict/specify the resources required
+// sets the limits of the container to restrict/specify the resources required
 // returns the total disk space requirement to be checked when validating output
-func DefineResources(seed *objects.Seed, inputSizeMiB float64) ([]string, float64, error) {
-	var resources []string
+func DefineResources(seed *objects.Seed, inputSizeMiB float64, spec *cliutil.RunSpec) (float64, error) {
 	var disk float64
 
 	for _, s := range seed.Job.Resources.Scalar {
-		value := fmt.Sprintf("%f", s.Value)
-		if s.Name == "mem" {
-			//resourceRequirement = inputVolume * inputMultiplier + constantValue
-			mem := (s.InputMultiplier * inputSizeMiB) + s.Value
-			mem = math.Max(mem, 4.0)        //docker memory requirement must be > 4MiB
+		amount := scalarAmount(s, inputSizeMiB)
+		value := fmt.Sprintf("%f", amount)
+		switch s.Name {
+		case "cpus":
+			spec.CPUs = amount
+		case "mem":
+			mem := math.Max(amount, 4.0)    //docker memory requirement must be > 4MiB
 			intMem := int64(math.Ceil(mem)) //docker expects integer, get the ceiling of the specified value and convert
-			resources = append(resources, "-m")
-			resources = append(resources, fmt.Sprintf("%dm", intMem))
+			spec.MemoryMiB = intMem
 			value = fmt.Sprintf("%d", intMem)
-		}
-		if s.Name == "disk" {
-			//resourceRequirement = inputVolume * inputMultiplier + constantValue
-			disk = (s.InputMultiplier * inputSizeMiB) + s.Value
-			value = fmt.Sprintf("%f", disk)
-		}
-		if s.Name == "sharedMem" {
-			//resourceRequirement = inputVolume * inputMultiplier + constantValue
-			mem := (s.InputMultiplier * inputSizeMiB) + s.Value
-			intMem := int64(math.Ceil(mem)) //docker expects integer, get the ceiling of the specified value and convert
-			resources = append(resources, fmt.Sprintf("--shm-size=%dm", intMem))
-			value = fmt.Sprintf("%d", intMem)
-		}
-		if s.Name == "gpus" {
-			var gpustring string
-			for g := 0; g < int(s.Value); g++ {
-				gpustring += strconv.Itoa(g) + ","
+		case "disk":
+			// the output directory is a bind mount so its size is always checked after the run
+			disk = amount
+			if cliutil.Runtime().SupportsStorageLimit() {
+				spec.StorageSizeMiB = int64(math.Ceil(disk))
+			} else {
+				util.PrintUtil("INFO: The storage driver of %s can't limit the container filesystem to %s MiB; "+
+					"only the output directory size is checked after the run\n", cliutil.Runtime().Name(), value)
 			}
-			gpustring = strings.Trim(gpustring, ",")
-			resources = append(resources, "--runtime=nvidia")
-			resources = append(resources, "-e")
-			resources = append(resources, fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", gpustring))
+		case "sharedMem":
+			intMem := int64(math.Ceil(amount)) //docker expects integer, get the ceiling of the specified value and convert
+			spec.ShmSizeMiB = intMem
+			value = fmt.Sprintf("%d", intMem)
+		case "gpus":
+			cliutil.Runtime().AddGPUs(spec, int(s.Value))
 			value = fmt.Sprintf("%d", int(s.Value))
 		}
 
+		// custom resources are passed to the job with their input multipliers applied
 		envVar := util.GetNormalizedVariable("ALLOCATED_" + s.Name)
-		resources = append(resources, "-e")
-		resources = append(resources, fmt.Sprintf("%s=%s", envVar, value))
+		spec.AddEnv(envVar, value)
 	}
 
-	return resources, disk, nil
+	return disk, nil
+}
+
+// scalarAmount returns the amount of a resource required for the size of the input in MiB
+func scalarAmount(s objects.Scalar, inputSizeMiB float64) float64 {
+	//resourceRequirement = inputVolume * inputMultiplier + constantValue
+	return (s.InputMultiplier * inputSizeMiB) + s.Value
 }
 
 //CheckRunOutput validates the output of the docker run command. Output data is
-// validated as defined in the seed.Job.Interface.Outputs.
-func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit float64) {
+// validated as defined in the seed.Job.Interface.Outputs. Returns the results of
+// the validation for inclusion in the run report
+func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit float64) OutputResults {
+	results := OutputResults{OutputDir: outDir, Valid: true, DiskLimitMiB: diskLimit}
+
 	// Validate any Outputs.Files
 	if seed.Job.Interface.Outputs.Files != nil {
 		util.PrintUtil("INFO: Validating output files found under %s...\n",
@@ -630,8 +1088,11 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		}
 		filepath.Walk(outDir, readSize)
 		sizeMB := float64(dirSize) / (1024.0 * 1024.0)
+		results.DiskUsageMiB = sizeMB
 		if diskLimit > 0 && sizeMB > diskLimit {
 			util.PrintUtil("ERROR: Output directory exceeds disk space limit (%f MiB vs. %f MiB)\n", sizeMB, diskLimit)
+			results.AddFinding(FindingError, "", outDir,
+				fmt.Sprintf("Output directory exceeds disk space limit (%f MiB vs. %f MiB)", sizeMB, diskLimit))
 		}
 
 		// For each defined Outputs file:
@@ -639,6 +1100,8 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		// 	#2 Check file names match output pattern
 		//  #3 Check number of files (if defined)
 		for _, f := range seed.Job.Interface.Outputs.Files {
+			fileResult := OutputFileResult{Name: f.Name, Pattern: f.Pattern, Required: f.Required, Multiple: f.Multiple}
+
 			// find all pattern matches in OUTPUT_DIR
 			matches, _ := filepath.Glob(path.Join(outDir, f.Pattern))
 
@@ -646,39 +1109,54 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 			count := 0
 			var matchList []string
 			for _, match := range matches {
+				if isSeedOutputFile(outDir, match) {
+					continue
+				}
 				ext := filepath.Ext(match)
 				mType := mime.TypeByExtension(ext)
 				if strings.Contains(mType, f.MediaType) ||
 					strings.Contains(f.MediaType, mType) {
 					count++
 					matchList = append(matchList, "\t"+match+"\n")
+					fileResult.Matches = append(fileResult.Matches, match)
 					metadata := match + ".metadata.json"
 					if _, err := os.Stat(metadata); err == nil {
 						schema := metadataSchema
 						if schema != "" {
 							schema = util.GetFullPath(schema, "")
 						}
+						metadataResult := MetadataResult{File: metadata, Valid: true}
 						err := ValidateSeedFile(false, schema, seed.SeedVersion, metadata, common_const.SchemaMetadata)
 						if err != nil {
 							util.PrintUtil("ERROR: Side-car metadata file %s validation error: %s", metadata, err.Error())
+							metadataResult.Valid = false
+							metadataResult.Error = err.Error()
+							results.AddFinding(FindingError, f.Name, metadata,
+								fmt.Sprintf("Side-car metadata file %s is invalid", metadata))
 						}
+						fileResult.Metadata = append(fileResult.Metadata, metadataResult)
 					}
 				}
 			}
+			fileResult.Count = count
 
 			expected := 1
-			errStr := "ERROR: Required file expected for output %v, %v found.\n"
+			errStr := "Required file expected for output %v, %v found."
 			if f.Multiple == true {
 				expected = 2
-				errStr = "ERROR: Multiple required files expected for output %v, %v found.\n"
+				errStr = "Multiple required files expected for output %v, %v found."
 			}
 
 			// Validate that any required fields are present
 			if f.Required && len(matchList) < expected {
-				util.PrintUtil(errStr, f.Name, strconv.Itoa(len(matchList)))
+				msg := fmt.Sprintf(errStr, f.Name, strconv.Itoa(len(matchList)))
+				util.PrintUtil("ERROR: %s\n", msg)
+				results.AddFinding(FindingError, f.Name, "", msg)
 			} else if !f.Multiple && len(matchList) > 1 {
 				util.PrintUtil("WARNING: Multiple files found for single output %v, %v found.\n",
 					f.Name, strconv.Itoa(len(matchList)))
+				results.AddFinding(FindingWarning, f.Name, "",
+					fmt.Sprintf("Multiple files found for single output %v, %v found.", f.Name, len(matchList)))
 				for _, s := range matchList {
 					util.PrintUtil(s)
 				}
@@ -690,6 +1168,7 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 					util.PrintUtil(s)
 				}
 			}
+			results.Files = append(results.Files, fileResult)
 		}
 	}
 
@@ -701,18 +1180,26 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 			filepath.Join(outDir, constants.ResultsFileManifestName))
 		// look for results manifest
 		manfile := filepath.Join(outDir, constants.ResultsFileManifestName)
+		results.JSON = &OutputJSONResult{File: manfile}
 		if _, err := os.Stat(manfile); os.IsNotExist(err) {
 			util.PrintUtil("ERROR: %s specified but cannot be found. %s\n Exiting testrunner.\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			results.JSON.Errors = append(results.JSON.Errors, err.Error())
+			results.AddFinding(FindingError, "", manfile,
+				fmt.Sprintf("%s specified but cannot be found.", constants.ResultsFileManifestName))
+			return results
 		}
+		results.JSON.Found = true
 
 		bites, err := ioutil.ReadFile(filepath.Join(outDir,
 			constants.ResultsFileManifestName))
 		if err != nil {
 			util.PrintUtil("ERROR: Error reading %s.%s\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			results.JSON.Errors = append(results.JSON.Errors, err.Error())
+			results.AddFinding(FindingError, "", manfile,
+				fmt.Sprintf("Error reading %s. %s", constants.ResultsFileManifestName, err.Error()))
+			return results
 		}
 
 		documentLoader := gojsonschema.NewStringLoader(string(bites))
@@ -720,7 +1207,10 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		if err != nil {
 			util.PrintUtil("ERROR: Error loading results manifest file: %s. %s\n Exiting testrunner.\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			results.JSON.Errors = append(results.JSON.Errors, err.Error())
+			results.AddFinding(FindingError, "", manfile,
+				fmt.Sprintf("%s is not valid JSON. %s", constants.ResultsFileManifestName, err.Error()))
+			return results
 		}
 
 		schemaFmt := "{ \"type\": \"object\", \"properties\": { %s }, \"required\": [ %s ] }"
@@ -755,22 +1245,31 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		if err != nil {
 			util.PrintUtil("ERROR: Error running validator: %s\n Exiting testrunner.\n",
 				err.Error())
-			return
+			results.JSON.Errors = append(results.JSON.Errors, err.Error())
+			results.AddFinding(FindingError, "", manfile,
+				fmt.Sprintf("Error validating %s. %s", constants.ResultsFileManifestName, err.Error()))
+			return results
 		}
 
 		if len(schemaResult.Errors()) == 0 {
 			util.PrintUtil("SUCCESS: Results manifest file is valid.\n")
+			results.JSON.Valid = true
 		}
 
 		for _, desc := range schemaResult.Errors() {
 			util.PrintUtil("ERROR: %s is invalid: - %s\n", constants.ResultsFileManifestName, desc)
+			results.JSON.Errors = append(results.JSON.Errors, desc.String())
+			results.AddFinding(FindingError, "", manfile,
+				fmt.Sprintf("%s is invalid: %s", constants.ResultsFileManifestName, desc))
 		}
 	}
+
+	return results
 }
 
 //PrintRunUsage prints the seed run usage arguments, then exits the program
 func PrintRunUsage() {
-	util.PrintUtil("\nUsage:\tseed run [-in IMAGE_NAME] [-M MANIFEST] [OPTIONS] \n")
+	util.PrintUtil("\nUsage:\tseed run [-in IMAGE_NAME] [-M MANIFEST] [-params PARAMS_FILE] [OPTIONS] \n")
 
 	util.PrintUtil("\nRuns Docker image defined by seed spec.\n")
 
@@ -781,6 +1280,10 @@ func PrintRunUsage() {
 		constants.ShortManifestFlag, constants.ManifestFlag)
 	util.PrintUtil("  -%s   -%s \t\tSpecifies the key/value input data values of the seed spec in the format INPUT_FILE_KEY=INPUT_FILE_VALUE\n",
 		constants.ShortInputsFlag, constants.InputsFlag)
+	util.PrintUtil("  -%s   -%s \t\tSpecifies the key/value json input values of the seed spec in the format JSON_KEY=VALUE or JSON_KEY=@FILE\n",
+		constants.ShortJsonFlag, constants.JsonFlag)
+	util.PrintUtil("  -%s \t\tJob parameter file (YAML, or JSON with a .json extension) declaring inputs, json inputs, settings and mounts; flags override it\n",
+		constants.ParamsFlag)
 	util.PrintUtil("  -%s   -%s \tSpecifies the key/value setting values of the seed spec in the format SETTING_KEY=VALUE\n",
 		constants.ShortSettingFlag, constants.SettingFlag)
 	util.PrintUtil("  -%s   -%s \t\tSpecifies the key/value mount values of the seed spec in the format MOUNT_KEY=HOST_PATH\n",
@@ -793,11 +1296,58 @@ func PrintRunUsage() {
 		constants.ShortQuietFlag, constants.QuietFlag)
 	util.PrintUtil("  -%s -%s \tRun docker image multiple times (i.e. -rep 5 runs the image 5 times)\n",
 		constants.ShortRepeatFlag, constants.RepeatFlag)
+	util.PrintUtil("  -%s \t\tStop the job after the given number of seconds (default is job.timeout from the seed manifest)\n",
+		constants.TimeoutFlag)
+	util.PrintUtil("  -%s \t\tFail the run if required outputs are missing or seed.outputs.json or side-car metadata files are invalid\n",
+		constants.StrictFlag)
+	util.PrintUtil("  -%s \tFail instead of warning when input files don't match the media types declared in the seed manifest\n",
+		constants.StrictMediaTypesFlag)
+	util.PrintUtil("  -%s \t\tFormat of the run report written to stdout: text, json or yaml (default is text)\n",
+		constants.FormatFlag)
+	util.PrintUtil("  -%s \t\tResolve and print the docker command and job command without starting a container\n",
+		constants.DryRunFlag)
+	util.PrintUtil("  -%s \tWrite a shell script that reproduces the run without the seed CLI (implies -%s)\n",
+		constants.EmitScriptFlag, constants.DryRunFlag)
+	util.PrintUtil("  -%s \t\tStart an interactive shell in the job container instead of running the job command\n",
+		constants.ShellFlag)
+	util.PrintUtil("  -%s \tKeep the container of a failed run and print how to inspect it\n",
+		constants.KeepOnFailureFlag)
+	util.PrintUtil("  -%s \t\tRun the job even if the host doesn't have the cpus, memory or disk space it requires\n",
+		constants.ForceRunFlag)
+	util.PrintUtil("  -%s \t\tUser to run the job container as: %s, %s, %s or UID[:GID] (default is %s)\n",
+		constants.RunAsFlag, constants.HostUser, constants.ImageUser, constants.RootUser, constants.HostUser)
+	util.PrintUtil("  -%s \t\tContainer runtime to use: docker or podman (default is $%s or docker)\n",
+		constants.RuntimeFlag, constants.RuntimeEnvVar)
 	util.PrintUtil("  -%s   -%s \t\tExternal Seed metadata schema file; Overrides built in schema to validate side-car metadata files\n",
 		constants.ShortSchemaFlag, constants.SchemaFlag)
 	return
 }
 
+// inputValues returns the values given for each input key in the order they were given
+func inputValues(inputs []string, normalize bool) map[string][]string {
+	values := make(map[string][]string)
+	for _, f := range inputs {
+		if f == "" {
+			//skip empty strings
+			continue
+		}
+		x := strings.SplitN(f, "=", 2)
+		if len(x) != 2 {
+			util.PrintUtil("ERROR: Input should be specified in KEY=VALUE format.\n")
+			util.PrintUtil("ERROR: Unknown key for input %v encountered.\n",
+				x)
+			continue
+		}
+
+		key := x[0]
+		if normalize {
+			key = util.GetNormalizedVariable(key)
+		}
+		values[key] = append(values[key], x[1])
+	}
+	return values
+}
+
 func inputMap(inputs []string, normalize bool) map[string]string {
 	// Ingest inputs into a map key = inputkey, value=inputpath
 	inMap := make(map[string]string)
@@ -2,9 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/ngageoint/seed-cli/cliutil"
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 )
@@ -55,8 +61,8 @@ func TestDockerRun(t *testing.T) {
 		metadataSchema := ""
 		version := "1.0.0"
 		DockerBuild(c.directory, version, "", "", ".", ".", "", false)
-		_, err := DockerRun(c.imageName, c.manifest, outputDir, metadataSchema,
-			c.inputs, c.json, c.settings, c.mounts, true, true)
+		_, err := DockerRun(RunOptions{ImageName: c.imageName, Manifest: c.manifest, OutputDir: outputDir, MetadataSchema: metadataSchema,
+			Inputs: c.inputs, Json: c.json, Settings: c.settings, Mounts: c.mounts, Remove: true, Quiet: true, Format: constants.TextFormat})
 		success := err == nil
 		if success != c.expected {
 			t.Errorf("DockerRun(%q, %q, %q, %q, %q, %q, %q) == %v, expected %v", c.imageName, c.manifest, outputDir, metadataSchema, c.inputs, c.settings, c.mounts, err, nil)
@@ -69,69 +75,123 @@ func TestDockerRun(t *testing.T) {
 	}
 }
 
+func TestJobTimeout(t *testing.T) {
+	cases := []struct {
+		seedFileName string
+		override     int
+		expected     int
+	}{
+		{"../examples/addition-job/seed.manifest.json", 0, 10},
+		{"../examples/addition-job/seed.manifest.json", 30, 30},
+		{"../examples/addition-job/seed.manifest.json", -1, 10},
+		{"../testdata/complete/seed.manifest.json", 0, 3600},
+	}
+
+	for _, c := range cases {
+		seedFileName := util.GetFullPath(c.seedFileName, "")
+		seed := objects.SeedFromManifestFile(seedFileName)
+		timeout := JobTimeout(&seed, c.override)
+		if timeout != c.expected {
+			t.Errorf("JobTimeout(%q, %v) == %v, expected %v", seedFileName, c.override, timeout, c.expected)
+		}
+	}
+}
+
+func TestRunShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	cases := []struct {
+		command  string
+		args     []string
+		expected int
+		success  bool
+	}{
+		{"sh", []string{"-c", "exit 0"}, 0, true},
+		{"sh", []string{"-c", "exit 3"}, 3, true},
+		{"seed-missing-command", nil, -1, false},
+	}
+
+	for _, c := range cases {
+		exitCode, err := RunShell(c.command, c.args)
+		if exitCode != c.expected || c.success != (err == nil) {
+			t.Errorf("RunShell(%q, %q) == %v, %v, expected %v", c.command, c.args, exitCode, err, c.expected)
+		}
+	}
+}
+
 func TestDefineInputs(t *testing.T) {
 	cases := []struct {
 		seedFileName     string
 		inputs           []string
 		expectedVol      string
 		expectedSize     string
-		expectedTempDir  string
 		expected         bool
 		expectedErrorMsg string
 	}{
 		{"../examples/addition-job/seed.manifest.json",
 			[]string{"INPUT_FILE=../examples/addition-job/inputs.txt"},
-			"[-v $INPUT_FILE$:$INPUT_FILE$ -e INPUT_FILE=$INPUT_FILE$]", "0.0",
-			"map[]", true, ""},
+			"[-v $EXAMPLES$/addition-job/inputs.txt:$EXAMPLES$/addition-job/inputs.txt -e INPUT_FILE=$EXAMPLES$/addition-job/inputs.txt]",
+			"0.0", true, ""},
 		{"../examples/extractor/seed.manifest.json",
 			[]string{"ZIP=../testdata/seed-scale.zip", "MULTIPLE=../testdata/"},
-			"[-v $MULTIPLE$:/$MULTIPLETEMP$ -e MULTIPLE=/$MULTIPLETEMP$ -v $ZIP$:$ZIP$ -e ZIP=$ZIP$]",
-			"0.1",
-			"map[MULTIPLE:$MULTIPLETEMP$]", true, ""}, // "ERROR: Permissions error linking to input files for input MULTIPLE."},
+			"[-v $TESTDATA$/seed-scale.zip:$TESTDATA$/seed-scale.zip -v $TESTDATA$:/seed/inputs/MULTIPLE:ro " +
+				"-e ZIP=$TESTDATA$/seed-scale.zip -e MULTIPLE=/seed/inputs/MULTIPLE]",
+			"0.2", true, ""},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../testdata/seed-scale.zip", "MULTIPLE=../testdata/*.csv"},
+			"[-v $TESTDATA$/seed-scale.zip:$TESTDATA$/seed-scale.zip " +
+				"-v $TESTDATA$/batch-test.csv:/seed/inputs/MULTIPLE/batch-test.csv:ro " +
+				"-v $TESTDATA$/empty-batch.csv:/seed/inputs/MULTIPLE/empty-batch.csv:ro " +
+				"-v $TESTDATA$/missing-keys.csv:/seed/inputs/MULTIPLE/missing-keys.csv:ro " +
+				"-e ZIP=$TESTDATA$/seed-scale.zip -e MULTIPLE=/seed/inputs/MULTIPLE]",
+			"0.1", true, ""},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../testdata/seed-scale.zip", "MULTIPLE=../testdata/complete/seed.manifest.json",
+				"MULTIPLE=../testdata/complete-denormalized/seed.manifest.json"},
+			"[-v $TESTDATA$/seed-scale.zip:$TESTDATA$/seed-scale.zip " +
+				"-v $TESTDATA$/complete/seed.manifest.json:/seed/inputs/MULTIPLE/seed.manifest.json:ro " +
+				"-v $TESTDATA$/complete-denormalized/seed.manifest.json:/seed/inputs/MULTIPLE/1-seed.manifest.json:ro " +
+				"-e ZIP=$TESTDATA$/seed-scale.zip -e MULTIPLE=/seed/inputs/MULTIPLE]",
+			"0.1", true, ""},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../testdata/seed-scale.zip", "MULTIPLE=../testdata/*.tif"},
+			"[]", "0.0", false, "No input files match"},
+		{"../examples/extractor/seed.manifest.json",
+			[]string{"ZIP=../testdata/seed-scale.zip", "ZIP=../testdata/seed-scale.zip"},
+			"[]", "0.0", false, "accepts a single file"},
 		{"../testdata/complete/seed.manifest.json",
 			[]string{"inPut-File=../testdata/seed-scale.zip"},
-			"[-v $inPut-File$:$inPut-File$ -e INPUT_FILE=$inPut-File$]", "0.1",
-			"map[]", true, ""},
+			"[-v $TESTDATA$/seed-scale.zip:$TESTDATA$/seed-scale.zip -e INPUT_FILE=$TESTDATA$/seed-scale.zip]", "0.1",
+			true, ""},
 		{"../testdata/complete-denormalized/seed.manifest.json",
 			[]string{"input-file=../testdata/seed-scale.zip"},
-			"[-v $input-file$:$input-file$ -e INPUT_FILE=$input-file$]", "0.1",
-			"map[]", true, ""},
+			"[-v $TESTDATA$/seed-scale.zip:$TESTDATA$/seed-scale.zip -e INPUT_FILE=$TESTDATA$/seed-scale.zip]", "0.1",
+			true, ""},
 		{"../testdata/complete-denormalized/seed.manifest.json",
 			[]string{"bad=../testdata/seed-scale.zip"},
-			"[]", "0.0",
-			"map[]", false, "Incorrect input data files key/values provided."},
+			"[]", "0.0", false, "Incorrect input data files key/values provided."},
 	}
 
+	replacer := strings.NewReplacer("$EXAMPLES$", util.GetFullPath("../examples", ""),
+		"$TESTDATA$", util.GetFullPath("../testdata", ""))
 	for _, c := range cases {
 		seedFileName := util.GetFullPath(c.seedFileName, "")
 		seed := objects.SeedFromManifestFile(seedFileName)
-		volumes, size, tempDir, err := DefineInputs(&seed, c.inputs)
+		spec := cliutil.RunSpec{}
+		size, err := DefineInputs(&seed, c.inputs, &spec)
 
 		if c.expected != (err == nil) {
 			t.Errorf("DefineInputs(%q, %q) == %v, expected %v", seedFileName, c.inputs, err, nil)
 		}
-
-		expectedVol := c.expectedVol
-		expectedTempDir := c.expectedTempDir
-		for _, f := range c.inputs {
-			x := strings.Split(f, "=")
-			tempDir, ok := tempDir[x[0]]
-			if ok {
-				defer util.RemoveAllFiles(tempDir)
-				tempVarStr := fmt.Sprintf("$%sTEMP$", x[0])
-				expectedVol = strings.Replace(expectedVol, tempVarStr, tempDir, -1)
-				path := util.GetFullPath(tempDir, "")
-				replaceNameStr := fmt.Sprintf("$%s$", x[0])
-				expectedVol = strings.Replace(expectedVol, replaceNameStr, path, -1)
-				expectedTempDir = strings.Replace(expectedTempDir, tempVarStr, tempDir, -1)
-			} else {
-				path := util.GetFullPath(x[1], "")
-				replaceNameStr := fmt.Sprintf("$%s$", x[0])
-				expectedVol = strings.Replace(expectedVol, replaceNameStr, path, -1)
-			}
+		if err != nil && !strings.Contains(err.Error(), c.expectedErrorMsg) {
+			t.Errorf("DefineInputs(%q, %q) == %v, expected %v", seedFileName, c.inputs, err.Error(), c.expectedErrorMsg)
 		}
-		tempStr := fmt.Sprintf("%v", volumes)
-		if expectedVol != tempStr {
+
+		expectedVol := filepath.FromSlash(replacer.Replace(c.expectedVol))
+		tempStr := filepath.FromSlash(fmt.Sprintf("%v", spec.Args()))
+		if err == nil && expectedVol != tempStr {
 			t.Errorf("DefineInputs(%q, %q) == \n%v, expected \n%v", seedFileName, c.inputs, tempStr, expectedVol)
 		}
 
@@ -139,11 +199,6 @@ func TestDefineInputs(t *testing.T) {
 		if c.expectedSize != sizeStr {
 			t.Errorf("DefineInputs(%q, %q) == %v, expected %v", seedFileName, c.inputs, sizeStr, c.expectedSize)
 		}
-
-		tempStr = fmt.Sprintf("%v", tempDir)
-		if expectedTempDir != tempStr {
-			t.Errorf("DefineInputs(%q, %q) == \n%v, expected \n%v", seedFileName, c.inputs, tempStr, expectedTempDir)
-		}
 	}
 }
 
@@ -167,6 +222,9 @@ func TestDefineInputJson(t *testing.T) {
 		{"../examples/addition-job/seed.manifest.json",
 			[]string{"ac=2", "bc=2"},
 			"[]", true, ""},
+		{"../examples/addition-job/seed.manifest.json",
+			[]string{"a=2.5", "b=two"},
+			"[]", false, ""},
 		{"../testdata/complete/seed.manifest.json",
 			[]string{"INPUT_JSON={a: 1, b: 2}"},
 			"[-e INPUT_JSON={a: 1, b: 2}]", true, ""},
@@ -175,14 +233,15 @@ func TestDefineInputJson(t *testing.T) {
 	for _, c := range cases {
 		seedFileName := util.GetFullPath(c.seedFileName, "")
 		seed := objects.SeedFromManifestFile(seedFileName)
-		settings, err := DefineInputJson(&seed, c.inputs)
+		spec := cliutil.RunSpec{}
+		err := DefineInputJson(&seed, c.inputs, &spec)
 
 		if c.expected != (err == nil) {
 			t.Errorf("DefineInputJson(%q, %q) == %v, expected %v", seedFileName, c.inputs, err, nil)
 		}
 
 		expectedSet := c.expectedSet
-		tempStr := fmt.Sprintf("%v", settings)
+		tempStr := fmt.Sprintf("%v", spec.Args())
 		if expectedSet != tempStr {
 			t.Errorf("DefineInputJson(%q, %q) == \n%v, expected \n%v", seedFileName, c.inputs, tempStr, expectedSet)
 		}
@@ -208,7 +267,8 @@ func TestDefineMounts(t *testing.T) {
 	for _, c := range cases {
 		seedFileName := util.GetFullPath(c.seedFileName, "")
 		seed := objects.SeedFromManifestFile(seedFileName)
-		volumes, err := DefineMounts(&seed, c.mounts)
+		spec := cliutil.RunSpec{}
+		err := DefineMounts(&seed, c.mounts, &spec)
 
 		if c.expected != (err == nil) {
 			t.Errorf("DefineMounts(%q, %q) == %v, expected %v", seedFileName, c.mounts, err, nil)
@@ -220,13 +280,48 @@ func TestDefineMounts(t *testing.T) {
 			path := util.GetFullPath(x[1], "")
 			expectedVol = strings.Replace(expectedVol, x[0], path, -1)
 		}
-		tempStr := fmt.Sprintf("%v", volumes)
+		tempStr := fmt.Sprintf("%v", spec.Args())
 		if expectedVol != tempStr {
 			t.Errorf("DefineMounts(%q, %q) == \n%v, expected \n%v", seedFileName, c.mounts, tempStr, expectedVol)
 		}
 	}
 }
 
+func TestRunUser(t *testing.T) {
+	seed := objects.Seed{}
+	rootSeed := objects.Seed{}
+	rootSeed.Job.Tags = []string{"image processing", constants.RunAsRootTag}
+	hostUser := cliutil.Runtime().HostUser()
+
+	cases := []struct {
+		seed     *objects.Seed
+		runAs    string
+		expected string
+		success  bool
+	}{
+		{&seed, "", hostUser, true},
+		{&seed, constants.HostUser, hostUser, true},
+		{&seed, constants.ImageUser, "", true},
+		{&seed, constants.RootUser, "0:0", true},
+		{&seed, "1000", "1000", true},
+		{&seed, "1000:100", "1000:100", true},
+		{&rootSeed, "", "0:0", true},
+		{&rootSeed, constants.HostUser, hostUser, true},
+		{&seed, "nobody", "", false},
+		{&seed, "1000:", "", false},
+	}
+
+	for _, c := range cases {
+		result, err := RunUser(c.seed, c.runAs)
+		if c.success != (err == nil) {
+			t.Errorf("RunUser(%v, %q) == %v, expected success %v", c.seed.Job.Tags, c.runAs, err, c.success)
+		}
+		if result != c.expected {
+			t.Errorf("RunUser(%v, %q) == %v, expected %v", c.seed.Job.Tags, c.runAs, result, c.expected)
+		}
+	}
+}
+
 func TestDefineResources(t *testing.T) {
 	cases := []struct {
 		seedFileName     string
@@ -237,23 +332,24 @@ func TestDefineResources(t *testing.T) {
 		expectedErrorMsg string
 	}{
 		{"../examples/addition-job/seed.manifest.json",
-			4.0, "[-e ALLOCATED_CPUS=0.100000 -m 16m -e ALLOCATED_MEM=16 -e ALLOCATED_DISK=5.000000 --shm-size=128m -e ALLOCATED_SHAREDMEM=128]", 5.0, true, ""},
+			4.0, "[-e ALLOCATED_CPUS=0.100000 -e ALLOCATED_MEM=16 -e ALLOCATED_DISK=5.000000 -e ALLOCATED_SHAREDMEM=128 --cpus=0.1 -m 16m --shm-size=128m]", 5.0, true, ""},
 		{"../examples/extractor/seed.manifest.json",
-			1.0, "[-e ALLOCATED_CPUS=1.000000 -m 16m -e ALLOCATED_MEM=16 --shm-size=1m -e ALLOCATED_SHAREDMEM=1 -e ALLOCATED_DISK=1.010000]", 1.01, true, ""},
+			1.0, "[-e ALLOCATED_CPUS=1.000000 -e ALLOCATED_MEM=16 -e ALLOCATED_SHAREDMEM=1 -e ALLOCATED_DISK=1.010000 --cpus=1 -m 16m --shm-size=1m]", 1.01, true, ""},
 		{"../examples/extractor/seed.manifest.json",
-			16.0, "[-e ALLOCATED_CPUS=1.000000 -m 16m -e ALLOCATED_MEM=16 --shm-size=1m -e ALLOCATED_SHAREDMEM=1 -e ALLOCATED_DISK=16.010000]", 16.01, true, ""},
+			16.0, "[-e ALLOCATED_CPUS=1.000000 -e ALLOCATED_MEM=16 -e ALLOCATED_SHAREDMEM=1 -e ALLOCATED_DISK=16.010000 --cpus=1 -m 16m --shm-size=1m]", 16.01, true, ""},
 	}
 
 	for _, c := range cases {
 		seedFileName := util.GetFullPath(c.seedFileName, "")
 		seed := objects.SeedFromManifestFile(seedFileName)
-		resources, outSize, err := DefineResources(&seed, c.inputSize)
+		spec := cliutil.RunSpec{}
+		outSize, err := DefineResources(&seed, c.inputSize, &spec)
 
 		if c.expectedResult != (err == nil) {
 			t.Errorf("DefineResources(%v, %v) returned unexpected error: %v", seedFileName, c.inputSize, err)
 		}
 
-		tempStr := fmt.Sprintf("%v", resources)
+		tempStr := fmt.Sprintf("%v", spec.Args())
 		if c.expectedResource != tempStr {
 			t.Errorf("DefineResources(%v, %v) == \n%v, expected \n%v", seedFileName, c.inputSize, tempStr, c.expectedResource)
 		}
@@ -265,45 +361,191 @@ func TestDefineResources(t *testing.T) {
 	}
 }
 
+func TestDefineCustomResources(t *testing.T) {
+	cases := []struct {
+		scalar           objects.Scalar
+		inputSize        float64
+		expectedResource string
+	}{
+		{objects.Scalar{Name: "cpus", Value: 0.5, InputMultiplier: 0.25}, 4.0, "[-e ALLOCATED_CPUS=1.500000 --cpus=1.5]"},
+		{objects.Scalar{Name: "mem", Value: 64, InputMultiplier: 2}, 10.5, "[-e ALLOCATED_MEM=85 -m 85m]"},
+		{objects.Scalar{Name: "sharedMem", Value: 1, InputMultiplier: 1}, 0.5, "[-e ALLOCATED_SHAREDMEM=2 --shm-size=2m]"},
+		{objects.Scalar{Name: "licenses", Value: 2}, 100.0, "[-e ALLOCATED_LICENSES=2.000000]"},
+		{objects.Scalar{Name: "tmpSpace", Value: 10, InputMultiplier: 3}, 2.0, "[-e ALLOCATED_TMPSPACE=16.000000]"},
+	}
+
+	for _, c := range cases {
+		seed := objects.Seed{}
+		seed.Job.Resources.Scalar = []objects.Scalar{c.scalar}
+		spec := cliutil.RunSpec{}
+		_, err := DefineResources(&seed, c.inputSize, &spec)
+		result := fmt.Sprintf("%v", spec.Args())
+		if err != nil || result != c.expectedResource {
+			t.Errorf("DefineResources(%v, %v) == %v, %v, expected %v", c.scalar, c.inputSize, result, err, c.expectedResource)
+		}
+	}
+}
+
 func TestDefineSettings(t *testing.T) {
 	cases := []struct {
 		seedFileName     string
 		settings         []string
 		expectedSet      string
+		expectedSecrets  string
 		expected         bool
 		expectedErrorMsg string
 	}{
 		{"../examples/addition-job/seed.manifest.json",
 			[]string{"SETTING_ONE=One", "SETTING_TWO=two"},
-			"[-e SETTING_ONE=One -e SETTING_TWO=two]", true, ""},
+			"[-e SETTING_ONE=One]", "map[SETTING_TWO:two]", true, ""},
 		{"../examples/extractor/seed.manifest.json",
-			[]string{"HELLO=Hello"}, "[-e HELLO=Hello]", true, ""},
+			[]string{"HELLO=Hello"}, "[-e HELLO=Hello]", "map[]", true, ""},
 		{"../testdata/complete/seed.manifest.json",
 			[]string{"version=1.0", "db-host=host", "db-pass=pass"},
-			"[-e VERSION=1.0 -e DB_HOST=host -e DB_PASS=pass]",
+			"[-e VERSION=1.0 -e DB_HOST=host]", "map[DB_PASS:pass]",
 			true, ""},
 		{"../testdata/complete/seed.manifest.json",
 			[]string{"version=1.0"},
-			"[]",
+			"[]", "map[]",
 			false, ""},
 		{"../testdata/complete-denormalized/seed.manifest.json",
 			[]string{"version=1.0", "db-host=host", "db-pass=pass"},
-			"[-e VERSION=1.0 -e DB_HOST=host -e DB_PASS=pass]",
+			"[-e VERSION=1.0 -e DB_HOST=host]", "map[DB_PASS:pass]",
 			true, ""},
 	}
 
 	for _, c := range cases {
 		seedFileName := util.GetFullPath(c.seedFileName, "")
 		seed := objects.SeedFromManifestFile(seedFileName)
-		settings, err := DefineSettings(&seed, c.settings)
+		spec := cliutil.RunSpec{}
+		secrets, err := DefineSettings(&seed, c.settings, &spec)
 
 		if c.expected != (err == nil) {
 			t.Errorf("DefineSettings(%q, %q) == %v, expected %v", seedFileName, c.settings, err, nil)
 		}
 
-		tempStr := fmt.Sprintf("%v", settings)
+		tempStr := fmt.Sprintf("%v", spec.Args())
 		if c.expectedSet != tempStr {
 			t.Errorf("DefineSettings(%q, %q) == \n%v, expected \n%v", seedFileName, c.settings, tempStr, c.expectedSet)
 		}
+
+		tempStr = fmt.Sprintf("%v", secrets)
+		if c.expectedSecrets != tempStr {
+			t.Errorf("DefineSettings(%q, %q) secrets == \n%v, expected \n%v", seedFileName, c.settings, tempStr, c.expectedSecrets)
+		}
+	}
+}
+
+func TestWriteEnvFile(t *testing.T) {
+	cases := []struct {
+		env      map[string]string
+		expected string
+		valid    bool
+	}{
+		{map[string]string{"DB_PASS": "pass"}, "DB_PASS=pass\n", true},
+		{map[string]string{"DB_PASS": "pa=ss word"}, "DB_PASS=pa=ss word\n", true},
+		{map[string]string{"DB_PASS": "multi\nline"}, "", false},
+	}
+
+	for _, c := range cases {
+		envFile, err := WriteEnvFile(c.env)
+		if envFile != "" {
+			defer os.Remove(envFile)
+		}
+		if c.valid != (err == nil) {
+			t.Errorf("WriteEnvFile(%v) returned unexpected error: %v", c.env, err)
+		}
+		if !c.valid {
+			continue
+		}
+
+		info, _ := os.Stat(envFile)
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+			t.Errorf("WriteEnvFile(%v) created file with mode %v, expected %v", c.env, info.Mode().Perm(), os.FileMode(0600))
+		}
+		contents, _ := ioutil.ReadFile(envFile)
+		if string(contents) != c.expected {
+			t.Errorf("WriteEnvFile(%v) wrote %q, expected %q", c.env, string(contents), c.expected)
+		}
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	cases := []struct {
+		str      string
+		secrets  map[string]string
+		expected string
+	}{
+		{"docker run -e VERSION=1.0 img /app pass", map[string]string{"DB_PASS": "pass"},
+			"docker run -e VERSION=1.0 img /app ****"},
+		{"docker run img", map[string]string{"DB_PASS": ""}, "docker run img"},
+		{"docker run img", nil, "docker run img"},
+	}
+
+	for _, c := range cases {
+		masked := MaskSecrets(c.str, c.secrets)
+		if masked != c.expected {
+			t.Errorf("MaskSecrets(%q, %v) == %q, expected %q", c.str, c.secrets, masked, c.expected)
+		}
+	}
+}
+
+func TestCheckRunOutput(t *testing.T) {
+	cases := []struct {
+		seedFileName  string
+		files         map[string]string
+		expectedFiles string
+		expectedJSON  string
+		expectedValid bool
+		expectedErrs  int
+	}{
+		{"../examples/addition-job/seed.manifest.json",
+			map[string]string{"1_output.txt": "3", "2_output.txt": "5",
+				"seed.outputs.json": `{"x": 1, "y": 2, "total": 3}`},
+			"[{OUTPUT_FILE 2 [1_output.txt 2_output.txt]}]", "found=true valid=true errors=0", true, 0},
+		{"../examples/addition-job/seed.manifest.json",
+			map[string]string{"seed.outputs.json": `{"x": "one"}`},
+			"[{OUTPUT_FILE 0 []}]", "found=true valid=false errors=1", false, 2},
+		{"../examples/addition-job/seed.manifest.json",
+			map[string]string{"1_output.txt": "3"},
+			"[{OUTPUT_FILE 1 [1_output.txt]}]", "found=false valid=false errors=1", false, 2},
+	}
+
+	for _, c := range cases {
+		outDir, _ := ioutil.TempDir("", "seed-output-")
+		defer os.RemoveAll(outDir)
+		for name, contents := range c.files {
+			ioutil.WriteFile(filepath.Join(outDir, name), []byte(contents), 0644)
+		}
+
+		seedFileName := util.GetFullPath(c.seedFileName, "")
+		seed := objects.SeedFromManifestFile(seedFileName)
+		results := CheckRunOutput(&seed, outDir, "", 0)
+
+		var files []string
+		for _, f := range results.Files {
+			var matches []string
+			for _, m := range f.Matches {
+				matches = append(matches, filepath.Base(m))
+			}
+			files = append(files, fmt.Sprintf("{%s %d %v}", f.Name, f.Count, matches))
+		}
+		tempStr := fmt.Sprintf("%v", files)
+		if tempStr != c.expectedFiles {
+			t.Errorf("CheckRunOutput(%q, %v) files == %v, expected %v", seedFileName, c.files, tempStr, c.expectedFiles)
+		}
+
+		tempStr = fmt.Sprintf("found=%v valid=%v errors=%d", results.JSON.Found, results.JSON.Valid, len(results.JSON.Errors))
+		if tempStr != c.expectedJSON {
+			t.Errorf("CheckRunOutput(%q, %v) json == %v, expected %v", seedFileName, c.files, tempStr, c.expectedJSON)
+		}
+
+		if results.Valid != c.expectedValid || len(results.Errors()) != c.expectedErrs {
+			t.Errorf("CheckRunOutput(%q, %v) valid == %v with %d errors, expected %v with %d errors", seedFileName, c.files,
+				results.Valid, len(results.Errors()), c.expectedValid, c.expectedErrs)
+		}
+		if c.expectedValid != (results.ValidationError() == nil) {
+			t.Errorf("CheckRunOutput(%q, %v) validation error == %v", seedFileName, c.files, results.ValidationError())
+		}
 	}
 }
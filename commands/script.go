@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-common/util"
+)
+
+//DryRunResult describes a run resolved by -dry-run without starting a container. It is
+// the document written to stdout for the json and yaml formats
+type DryRunResult struct {
+	Image         string   `json:"image"`
+	DockerCommand string   `json:"dockerCommand"`
+	DockerArgs    []string `json:"dockerArgs"`
+	Command       string   `json:"command"`
+	OutputDir     string   `json:"outputDir"`
+	Secrets       []string `json:"secrets,omitempty"`
+}
+
+//CreateRunScript creates the executable shell script written by -emit-script. The commands
+// of each dry run are appended to it with WriteRunScript
+func CreateRunScript(fileName string) (*os.File, error) {
+	fileName = util.GetFullPath(fileName, "")
+	f, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to create script %s: %s\n", fileName, err.Error())
+	}
+
+	_, err = io.WriteString(f, "#!/bin/sh\n# Generated by seed. Reproduces seed runs without the seed CLI.\n")
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("ERROR: Unable to write script %s: %s\n", fileName, err.Error())
+	}
+	return f, nil
+}
+
+//WriteRunScript appends the commands reproducing a dry run to the given script. Secret
+// settings aren't written to the script; they must be set in the environment of the
+// script and are passed to the container by name
+func WriteRunScript(w io.Writer, run *DryRunResult) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("\n# " + run.Image + "\n")
+	buffer.WriteString("# job command: " + run.Command + "\n")
+	for _, s := range run.Secrets {
+		buffer.WriteString(fmt.Sprintf(": \"${%s:?%s must be set to the value of the secret setting}\"\n", s, s))
+	}
+	buffer.WriteString("mkdir -p " + ShellQuote(run.OutputDir) + "\n")
+	buffer.WriteString(ShellJoin(run.DockerCommand, run.DockerArgs) + "\n")
+
+	_, err := io.WriteString(w, buffer.String())
+	return err
+}
+
+//ShellJoin returns the command and its arguments as a single line that can be pasted
+// into a POSIX shell
+func ShellJoin(command string, args []string) string {
+	quoted := []string{ShellQuote(command)}
+	for _, arg := range args {
+		quoted = append(quoted, ShellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+//ShellQuote quotes a string for a POSIX shell. Strings made up only of characters that
+// are never special to the shell are returned unchanged
+func ShellQuote(str string) string {
+	if str == "" {
+		return "''"
+	}
+	safe := true
+	for _, c := range str {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_=+/.,:@%", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return str
+	}
+	return "'" + strings.Replace(str, "'", `'"'"'`, -1) + "'"
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
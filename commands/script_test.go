@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	cases := []struct {
+		str      string
+		expected string
+	}{
+		{"", "''"},
+		{"docker", "docker"},
+		{"INPUT_FILE=/data/inputs.txt", "INPUT_FILE=/data/inputs.txt"},
+		{"--shm-size=128m", "--shm-size=128m"},
+		{"/data/my inputs.txt", "'/data/my inputs.txt'"},
+		{"$OUTPUT_DIR", "'$OUTPUT_DIR'"},
+		{"it's", `'it'"'"'s'`},
+		{`{"x": 1}`, `'{"x": 1}'`},
+	}
+
+	for _, c := range cases {
+		result := ShellQuote(c.str)
+		if result != c.expected {
+			t.Errorf("ShellQuote(%q) == %v, expected %v", c.str, result, c.expected)
+		}
+	}
+}
+
+func TestWriteRunScript(t *testing.T) {
+	run := DryRunResult{Image: "addition-job-0.0.1-seed:1.0.0", DockerCommand: "docker",
+		DockerArgs: []string{"run", "-e", "DB_PASS", "-e", "NOTE=hello world", "addition-job-0.0.1-seed:1.0.0", "echo", "it's"},
+		Command:    "echo it's", OutputDir: "/tmp/out dir", Secrets: []string{"DB_PASS"}}
+
+	var script bytes.Buffer
+	err := WriteRunScript(&script, &run)
+	if err != nil {
+		t.Errorf("WriteRunScript(%v) == %v, expected %v", run, err, nil)
+	}
+
+	expected := []string{
+		": \"${DB_PASS:?DB_PASS must be set to the value of the secret setting}\"",
+		"mkdir -p '/tmp/out dir'",
+		`docker run -e DB_PASS -e 'NOTE=hello world' addition-job-0.0.1-seed:1.0.0 echo 'it'"'"'s'`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(script.String(), e+"\n") {
+			t.Errorf("WriteRunScript(%v) == %v, expected line %v", run, script.String(), e)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// the quoted arguments should survive a round trip through the shell
+	tempDir, _ := ioutil.TempDir("", "seed-script-")
+	defer os.RemoveAll(tempDir)
+	fileName := filepath.Join(tempDir, "run.sh")
+	f, err := CreateRunScript(fileName)
+	if err != nil {
+		t.Errorf("CreateRunScript(%q) == %v, expected %v", fileName, err, nil)
+		return
+	}
+	WriteRunScript(f, &DryRunResult{DockerCommand: "printf", DockerArgs: []string{"%s|", "a b", "it's", "$HOME"},
+		OutputDir: filepath.Join(tempDir, "out")})
+	f.Close()
+
+	out, err := exec.Command("sh", fileName).Output()
+	if err != nil || string(out) != "a b|it's|$HOME|" {
+		t.Errorf("sh %v == %q, %v, expected %q", fileName, string(out), err, "a b|it's|$HOME|")
+	}
+}
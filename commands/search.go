@@ -10,6 +10,13 @@ import (
 	"github.com/ngageoint/seed-common/util"
 )
 
+//SearchResult is the document written by seed search for the json and yaml formats
+type SearchResult struct {
+	Registry     string   `json:"registry"`
+	Org          string   `json:"org,omitempty"`
+	Repositories []string `json:"repositories"`
+}
+
 //DockerSearch executes the seed search command
 func DockerSearch(url, org, filter, username, password string) ([]string, error) {
 	_ = filter //TODO: add filter
@@ -29,9 +36,33 @@ func DockerSearch(url, org, filter, username, password string) ([]string, error)
 	return nil, err
 }
 
+//PrintSearchResults prints the repositories found by DockerSearch as text or writes them
+// to stdout as a json or yaml SearchResult
+func PrintSearchResults(url, org string, results []string, format string) error {
+	if IsStructuredFormat(format) {
+		if url == "" {
+			url = common_const.DefaultRegistry
+		}
+		if results == nil {
+			results = []string{}
+		}
+		return WriteResult(format, SearchResult{Registry: url, Org: org, Repositories: results})
+	}
+
+	if len(results) > 0 {
+		util.PrintUtil("Found %v Repositories:\n", len(results))
+		for _, r := range results {
+			util.PrintUtil("%s\n", r)
+		}
+	} else {
+		util.PrintUtil("No repositories found.\n")
+	}
+	return nil
+}
+
 //PrintSearchUsage prints the seed search usage information, then exits the program
 func PrintSearchUsage() {
-	util.PrintUtil("\nUsage:\tseed search [-r REGISTRY_NAME] [-O ORGANIZATION_NAME] [-f FILTER] [-u Username] [-p password]\n")
+	util.PrintUtil("\nUsage:\tseed search [-r REGISTRY_NAME] [-O ORGANIZATION_NAME] [-f FILTER] [-u Username] [-p password] [-format FORMAT]\n")
 	util.PrintUtil("\nAllows for discovery of seed compliant images hosted within a Docker registry.\n")
 	util.PrintUtil("\nOptions:\n")
 	util.PrintUtil("  -%s -%s\tSpecifies a specific registry to search (default is index.docker.io).\n",
@@ -44,6 +75,8 @@ func PrintSearchUsage() {
 		constants.ShortUserFlag, constants.UserFlag)
 	util.PrintUtil("  -%s -%s\tPassword to login to remote registry (default is anonymous).\n",
 		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s\t\tFormat of the results written to stdout: text, json or yaml (default is text).\n",
+		constants.FormatFlag)
 	return
 }
 
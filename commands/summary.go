@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+//BatchFailedError is returned by BatchRun when rows of the batch failed
+type BatchFailedError struct {
+	Failed int
+	Total  int
+}
+
+func (e *BatchFailedError) Error() string {
+	return fmt.Sprintf("ERROR: %d of %d batch rows failed.", e.Failed, e.Total)
+}
+
+//ExitCode returns the exit code of seed batch: FailedRowsExitCode plus the number of rows
+// that failed, up to MaxFailedRowsExitCode
+func (e *BatchFailedError) ExitCode() int {
+	if code := constants.FailedRowsExitCode + e.Failed; code < constants.MaxFailedRowsExitCode {
+		return code
+	}
+	return constants.MaxFailedRowsExitCode
+}
+
+//CheckSummaryFormat returns an error if format is not one of the batch summary formats
+func CheckSummaryFormat(format string) error {
+	switch format {
+	case constants.CsvFormat, constants.JsonFormat, constants.JunitFormat:
+		return nil
+	}
+	return fmt.Errorf("ERROR: Unknown batch summary format %s. Supported formats are %s, %s and %s.\n",
+		format, constants.CsvFormat, constants.JsonFormat, constants.JunitFormat)
+}
+
+//BatchSummaryFile returns the default path of the summary of the batch writing to outdir. It
+// is next to the output directory like the batch state file
+func BatchSummaryFile(outdir, format string) string {
+	ext := format
+	if format == constants.JunitFormat {
+		ext = "xml"
+	}
+	return batchFile(outdir, ".summary."+ext)
+}
+
+//WriteBatchSummary writes the summary of a batch with one record per row to fileName as a CSV
+// file, a json BatchResult document or a JUnit XML report
+func WriteBatchSummary(fileName, format string, result *BatchResult) error {
+	var data []byte
+	var err error
+	switch format {
+	case constants.CsvFormat:
+		data, err = batchSummaryCsv(result)
+	case constants.JsonFormat:
+		data, err = json.MarshalIndent(result, "", "  ")
+		data = append(data, '\n')
+	case constants.JunitFormat:
+		data, err = batchSummaryJunit(result)
+	default:
+		err = CheckSummaryFormat(format)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(fileName, data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("ERROR: Unable to write batch summary %s: %s\n", fileName, err.Error())
+	}
+	return nil
+}
+
+// batchSummaryCsv returns the rows of a batch as CSV. Multiple inputs and json inputs of a row
+// are separated by spaces
+func batchSummaryCsv(result *BatchResult) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := csv.NewWriter(&buffer)
+	w.Write([]string{"row", "inputs", "json", "outputDir", "status", "exitCode", "errorName", "errorCategory",
+		"durationSeconds", "outputsValid", "attempts", "error"})
+	for i, row := range result.Rows {
+		valid := ""
+		if row.OutputsValid != nil {
+			valid = strconv.FormatBool(*row.OutputsValid)
+		}
+		w.Write([]string{strconv.Itoa(i + 1), strings.Join(row.Inputs, " "), strings.Join(row.Json, " "), row.OutputDir,
+			row.Status, strconv.Itoa(row.ExitCode), row.ErrorName, row.ErrorCategory,
+			strconv.FormatFloat(row.Duration, 'f', 3, 64), valid, strconv.Itoa(row.Attempts), row.Error})
+	}
+	w.Flush()
+	return buffer.Bytes(), w.Error()
+}
+
+// junitSuite is the testsuite element of a JUnit XML report
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase is the testcase element of a JUnit XML report
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitProblem is the failure, error or skipped element of a JUnit test case
+type junitProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// batchSummaryJunit returns the rows of a batch as a JUnit XML test suite named after the
+// image. Failed rows are failures, rows that timed out are errors and cancelled rows are skipped
+func batchSummaryJunit(result *BatchResult) ([]byte, error) {
+	suite := junitSuite{Name: result.Image, Tests: len(result.Rows)}
+	total := 0.0
+	for _, row := range result.Rows {
+		total += row.Duration
+		c := junitCase{ClassName: result.Image, Name: batchRowName(row), Time: fmt.Sprintf("%.3f", row.Duration),
+			SystemOut: row.OutputDir}
+		problem := &junitProblem{Message: row.Error, Type: row.Status, Text: row.Error}
+		if row.ErrorName != "" {
+			problem.Type = row.ErrorName
+			problem.Text = fmt.Sprintf("%s (%s error, exit code %d)\n%s", row.ErrorName, row.ErrorCategory, row.ExitCode, row.Error)
+		}
+		switch row.Status {
+		case RunStatusFailed:
+			c.Failure = problem
+			suite.Failures++
+		case RunStatusTimeout:
+			c.Error = problem
+			suite.Errors++
+		case RunStatusCancelled:
+			c.Skipped = problem
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
+
+// batchRowName names a row by its directory within the batch output directory, which holds the
+// row number and the names of its input files
+func batchRowName(row BatchRowResult) string {
+	if name := filepath.Base(row.RowDir); name != "." && name != string(filepath.Separator) {
+		return name
+	}
+	return strings.Join(row.Inputs, " ")
+}
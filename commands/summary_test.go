@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+func TestWriteBatchSummary(t *testing.T) {
+	valid, invalid := true, false
+	result := &BatchResult{Image: "extractor-0.1.0-seed:0.1.0", OutputDir: "/out", Failed: 2, Rows: []BatchRowResult{
+		{Inputs: []string{"ZIP=a.zip"}, RowDir: "/out/1-a.zip", OutputDir: "/out/1-a.zip/20180101_000000", Status: RunStatusSuccess,
+			Duration: 12.5, OutputsValid: &valid, Attempts: 2},
+		{Inputs: []string{"ZIP=b, c.zip"}, Json: []string{"N=1"}, RowDir: "/out/2-b, c.zip", OutputDir: "/out/2-b, c.zip", Status: RunStatusFailed,
+			ExitCode: 2, ErrorName: "bad-zip", ErrorCategory: "data", Duration: 1, OutputsValid: &invalid, Error: "ERROR: bad <zip>", Attempts: 1},
+		{Inputs: []string{"ZIP=d.zip"}, RowDir: "/out/3-d.zip", OutputDir: "/out/3-d.zip", Status: RunStatusTimeout, ExitCode: 124, Error: "ERROR: timeout"},
+		{Inputs: []string{"ZIP=e.zip"}, RowDir: "/out/4-e.zip", OutputDir: "/out/4-e.zip", Status: RunStatusCancelled, ExitCode: 130},
+	}}
+
+	dir, _ := ioutil.TempDir("", "seed-summary-")
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		format   string
+		expected []string
+	}{
+		{constants.CsvFormat, []string{
+			"row,inputs,json,outputDir,status,exitCode,errorName,errorCategory,durationSeconds,outputsValid,attempts,error\n",
+			"1,ZIP=a.zip,,/out/1-a.zip/20180101_000000,SUCCESS,0,,,12.500,true,2,\n",
+			"2,\"ZIP=b, c.zip\",N=1,\"/out/2-b, c.zip\",FAILED,2,bad-zip,data,1.000,false,1,ERROR: bad <zip>\n",
+			"4,ZIP=e.zip,,/out/4-e.zip,CANCELLED,130,,,0.000,,0,\n"}},
+		{constants.JsonFormat, []string{`"failed": 2`, `"errorName": "bad-zip"`, `"durationSeconds": 12.5`, `"outputsValid": false`}},
+		{constants.JunitFormat, []string{
+			`<testsuite name="extractor-0.1.0-seed:0.1.0" tests="4" failures="1" errors="1" skipped="1" time="13.500">`,
+			`<testcase classname="extractor-0.1.0-seed:0.1.0" name="1-a.zip" time="12.500">`,
+			`<system-out>/out/1-a.zip/20180101_000000</system-out>`,
+			`<failure message="ERROR: bad &lt;zip&gt;" type="bad-zip">bad-zip (data error, exit code 2)`,
+			`<error message="ERROR: timeout" type="TIMEOUT">ERROR: timeout</error>`,
+			`<skipped type="CANCELLED"></skipped>`,
+			`<system-out>/out/4-e.zip</system-out>`}},
+	}
+
+	for _, c := range cases {
+		fileName := filepath.Join(dir, "summary."+c.format)
+		if err := WriteBatchSummary(fileName, c.format, result); err != nil {
+			t.Errorf("WriteBatchSummary(%q) == %v, expected no error", c.format, err)
+			continue
+		}
+		data, _ := ioutil.ReadFile(fileName)
+		for _, e := range c.expected {
+			if !strings.Contains(string(data), e) {
+				t.Errorf("WriteBatchSummary(%q) wrote %s, expected it to contain %v", c.format, data, e)
+			}
+		}
+		if c.format == constants.JsonFormat {
+			var decoded BatchResult
+			if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Rows) != len(result.Rows) {
+				t.Errorf("WriteBatchSummary(%q) wrote %s, expected a BatchResult with %d rows", c.format, data, len(result.Rows))
+			}
+		}
+	}
+
+	if err := WriteBatchSummary(filepath.Join(dir, "summary.html"), "html", result); err == nil {
+		t.Errorf("WriteBatchSummary(%q) == nil, expected an error", "html")
+	}
+}
+
+func TestBatchFailedError(t *testing.T) {
+	cases := []struct {
+		failed   int
+		expected int
+	}{
+		{1, 201},
+		{42, 242},
+		{50, 250},
+		{6000, 250},
+	}
+
+	for _, c := range cases {
+		err := &BatchFailedError{Failed: c.failed, Total: 10000}
+		if result := err.ExitCode(); result != c.expected {
+			t.Errorf("BatchFailedError{%d}.ExitCode() == %v, expected %v", c.failed, result, c.expected)
+		}
+	}
+}
@@ -2,12 +2,10 @@ package commands
 
 import (
 	"errors"
-	"os"
 	"strings"
-	"time"
 
+	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/constants"
-	common_const "github.com/ngageoint/seed-common/constants"
 	"github.com/ngageoint/seed-common/objects"
 	RegistryFactory "github.com/ngageoint/seed-common/registry"
 	"github.com/ngageoint/seed-common/util"
@@ -46,13 +44,8 @@ func DockerUnpublish(image, manifest, registry, org, username, password string)
 	}
 
 	if username != "" {
-		//set config dir so we don't stomp on other users' logins with sudo
-		configDir := common_const.DockerConfigDir + time.Now().Format(time.RFC3339)
-		os.Setenv(common_const.DockerConfigKey, configDir)
-		defer util.RemoveAllFiles(configDir)
-		defer os.Unsetenv(common_const.DockerConfigKey)
-
-		err := util.Login(registry, username, password)
+		logout, err := cliutil.Runtime().Login(registry, username, password)
+		defer logout()
 		if err != nil {
 			util.PrintUtil(err.Error())
 		}
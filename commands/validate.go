@@ -14,8 +14,64 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
+//ValidationResult is the document written by seed validate for the json and yaml formats
+type ValidationResult struct {
+	File   string              `json:"file"`
+	Valid  bool                `json:"valid"`
+	Errors []ValidationProblem `json:"errors"`
+}
+
+//ValidationProblem is a single problem found by ValidateSeedFile. Field and Context locate a
+// schema validation error in the seed file, and Names lists the names of a name collision
+type ValidationProblem struct {
+	Message string   `json:"message"`
+	Field   string   `json:"field,omitempty"`
+	Context string   `json:"context,omitempty"`
+	Names   []string `json:"names,omitempty"`
+	// schema is set for schema validation errors, which are printed under a heading
+	schema bool
+	// highlight prints the message in red, as for warnings treated as errors
+	highlight bool
+}
+
+//ValidationError is returned by ValidateSeedFile for a seed file that isn't valid. Error returns
+// the problems as printed by seed validate
+type ValidationError struct {
+	File     string
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	var buffer bytes.Buffer
+	for i, p := range e.Problems {
+		switch {
+		case p.schema:
+			if i == 0 {
+				buffer.WriteString("ERROR:" + e.File + " is not valid. See errors:\n")
+			}
+			buffer.WriteString("-ERROR " + p.Message + "\n")
+			buffer.WriteString("\tField: " + p.Field + "\n")
+			buffer.WriteString("\tContext: " + p.Context + "\n")
+		case p.highlight:
+			buffer.WriteString("\033[41mERROR: " + p.Message + "\033[0m\n")
+		default:
+			buffer.WriteString("ERROR: " + p.Message + "\n")
+		}
+		for _, n := range p.Names {
+			buffer.WriteString("\t" + n + "\n")
+		}
+	}
+	return buffer.String()
+}
+
+// add records a problem that isn't a schema validation error
+func (e *ValidationError) add(message string) {
+	e.Problems = append(e.Problems, ValidationProblem{Message: message})
+}
+
 //Validate seed validate: Validate seed.manifest.json. Does not require docker
-func Validate(warningsAsErrors bool, schemaFile, dir, version string) error {
+// The result is written to stdout as a ValidationResult for the json and yaml formats
+func Validate(warningsAsErrors bool, schemaFile, dir, version, format string) error {
 	var err error = nil
 	var seedFileName string
 
@@ -30,9 +86,27 @@ func Validate(warningsAsErrors bool, schemaFile, dir, version string) error {
 
 	err = ValidateSeedFile(warningsAsErrors, schemaFile, version, seedFileName, common_const.SchemaManifest)
 
+	if IsStructuredFormat(format) {
+		result := ValidationResult{File: seedFileName, Valid: err == nil, Errors: ValidationErrors(err)}
+		if wErr := WriteResult(format, result); wErr != nil {
+			return wErr
+		}
+	}
+
 	return err
 }
 
+//ValidationErrors returns the problems of the error returned by ValidateSeedFile. An error
+// that isn't a *ValidationError, such as a seed file that can't be read, is a single problem
+func ValidationErrors(err error) []ValidationProblem {
+	if invalid, ok := err.(*ValidationError); ok {
+		return invalid.Problems
+	} else if err != nil {
+		return []ValidationProblem{{Message: strings.TrimSpace(err.Error())}}
+	}
+	return []ValidationProblem{}
+}
+
 //PrintValidateUsage prints the seed validate usage, then exits the program
 func PrintValidateUsage() {
 	util.PrintUtil("\nUsage:\tseed validate [OPTIONS] \n")
@@ -48,6 +122,8 @@ func PrintValidateUsage() {
 		constants.ShortVersionFlag, constants.VersionFlag)
 	util.PrintUtil("  -%s -%s\tSpecifies whether to treat warnings as errors during validation\n",
 		constants.ShortWarnAsErrorsFlag, constants.WarnAsErrorsFlag)
+	util.PrintUtil("  -%s\t\tFormat of the validation result written to stdout: text, json or yaml (default is text)\n",
+		constants.FormatFlag)
 	return
 }
 
@@ -104,14 +180,10 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 	}
 
 	// Validation failed. Print results
-	var buffer bytes.Buffer
-	if !result.Valid() {
-		buffer.WriteString("ERROR:" + seedFileName + " is not valid. See errors:\n")
-		for _, e := range result.Errors() {
-			buffer.WriteString("-ERROR " + e.Description() + "\n")
-			buffer.WriteString("\tField: " + e.Field() + "\n")
-			buffer.WriteString("\tContext: " + e.Context().String() + "\n")
-		}
+	invalid := &ValidationError{File: seedFileName}
+	for _, e := range result.Errors() {
+		invalid.Problems = append(invalid.Problems, ValidationProblem{Message: e.Description(), Field: e.Field(),
+			Context: e.Context().String(), schema: true})
 	}
 
 	//Identify any name collisions for the following reserved variables:
@@ -121,8 +193,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 
 	//skip resource and name collision checking for metadata files
 	if schemaType != common_const.SchemaManifest {
-		if buffer.String() != "" {
-			return errors.New(buffer.String())
+		if len(invalid.Problems) > 0 {
+			return invalid
 		}
 		return nil
 	}
@@ -148,8 +220,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 			name := util.GetNormalizedVariable(s.Name)
 			allocated = append(allocated, "ALLOCATED_"+strings.ToUpper(name))
 			if util.IsReserved(s.Name, nil) {
-				buffer.WriteString("ERROR: job.resources.scalar Name " +
-					s.Name + " is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.resources.scalar Name " +
+					s.Name + " is a reserved variable. Please choose a different name value.")
 			}
 
 			util.IsInUse(s.Name, "job.resources.scalar", vars)
@@ -161,8 +233,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		for _, f := range seed.Job.Interface.Inputs.Files {
 			// check against the ALLOCATED_* and OUTPUT_DIR
 			if util.IsReserved(f.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.inputs.files Name " +
-					f.Name + " is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.inputs.files Name " +
+					f.Name + " is a reserved variable. Please choose a different name value.")
 			}
 
 			util.IsInUse(f.Name, "job.interface.inputs.files", vars)
@@ -173,8 +245,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 	if seed.Job.Interface.Inputs.Json != nil {
 		for _, f := range seed.Job.Interface.Inputs.Json {
 			if util.IsReserved(f.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.inputs.json Name " +
-					f.Name + " is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.inputs.json Name " +
+					f.Name + " is a reserved variable. Please choose a different name value.")
 			}
 
 			util.IsInUse(f.Name, "job.interface.inputs.json", vars)
@@ -186,8 +258,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		for _, f := range seed.Job.Interface.Outputs.Files {
 			// check against the ALLOCATED_* and OUTPUT_DIR
 			if util.IsReserved(f.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.outputs.files Name " +
-					f.Name + " is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.outputs.files Name " +
+					f.Name + " is a reserved variable. Please choose a different name value.")
 			}
 			util.IsInUse(f.Name, "job.interface.outputs.files", vars)
 			util.IsNormalized(f.Name, "job.interface.outputs.files", normalizedWarnings)
@@ -198,8 +270,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		for _, f := range seed.Job.Interface.Outputs.JSON {
 			// check against the ALLOCATED_* and OUTPUT_DIR
 			if util.IsReserved(f.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.outputData.json Name " +
-					f.Name + " is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.outputData.json Name " +
+					f.Name + " is a reserved variable. Please choose a different name value.")
 			}
 			util.IsInUse(f.Name, "job.interface.outputs.json", vars)
 			util.IsNormalized(f.Name, "job.interface.outputs.json", normalizedWarnings)
@@ -210,8 +282,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		for _, m := range seed.Job.Interface.Mounts {
 			// check against the ALLOCATED_* and OUTPUT_DIR
 			if util.IsReserved(m.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.mounts Name " + m.Name +
-					" is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.mounts Name " + m.Name +
+					" is a reserved variable. Please choose a different name value.")
 			}
 			util.IsInUse(m.Name, "job.interface.mounts", vars)
 			util.IsNormalized(m.Name, "job.interface.mounts", normalizedWarnings)
@@ -222,8 +294,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		for _, s := range seed.Job.Interface.Settings {
 			// check against the ALLOCATED_* and OUTPUT_DIR
 			if util.IsReserved(s.Name, allocated) {
-				buffer.WriteString("ERROR: job.interface.settings Name " + s.Name +
-					" is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.interface.settings Name " + s.Name +
+					" is a reserved variable. Please choose a different name value.")
 			}
 			util.IsInUse(s.Name, "job.interface.settings", vars)
 			util.IsNormalized(s.Name, "job.interface.settings", normalizedWarnings)
@@ -233,8 +305,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 	if seed.Job.Errors != nil {
 		for _, e := range seed.Job.Errors {
 			if util.IsReserved(e.Name, allocated) {
-				buffer.WriteString("ERROR: job.errors Name " + e.Name +
-					" is a reserved variable. Please choose a different name value.\n")
+				invalid.add("job.errors Name " + e.Name +
+					" is a reserved variable. Please choose a different name value.")
 			}
 			util.IsInUse(e.Name, "job.errors", vars)
 		}
@@ -243,11 +315,8 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 	// Find any name collisions
 	for key, val := range vars {
 		if len(val) > 1 {
-			buffer.WriteString("ERROR: Multiple Name values are assigned the same " +
-				key + " Name value. Each Name value must be unique.\n")
-			for _, v := range val {
-				buffer.WriteString("\t" + v + "\n")
-			}
+			invalid.Problems = append(invalid.Problems, ValidationProblem{Message: "Multiple Name values are assigned the same " +
+				key + " Name value. Each Name value must be unique.", Names: val})
 		}
 	}
 
@@ -257,15 +326,15 @@ func ValidateSeedFile(warningsAsErrors bool, schemaFile, version, seedFileName s
 		msg := fmt.Sprintf("Name value " + val + "." + key + " should be normalized.")
 
 		if warningsAsErrors {
-			buffer.WriteString(fmt.Sprintf("\033[41mERROR: " + msg + "\033[0m\n"))
+			invalid.Problems = append(invalid.Problems, ValidationProblem{Message: msg, highlight: true})
 		} else {
 			util.PrintUtil("\033[30;43mWARNING: " + msg + "\033[0m\n")
 		}
 	}
 
 	// Return error if issues found
-	if buffer.String() != "" {
-		return errors.New(buffer.String())
+	if len(invalid.Problems) > 0 {
+		return invalid
 	}
 
 	// Validation succeeded
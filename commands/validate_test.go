@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -49,3 +51,39 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestValidationErrors(t *testing.T) {
+	invalid := &ValidationError{File: "seed.manifest.json", Problems: []ValidationProblem{
+		{Message: "job is required", Field: "(root)", Context: "(root)", schema: true},
+		{Message: "Multiple Name values are assigned the same INPUT Name value. Each Name value must be unique.",
+			Names: []string{"job.interface.inputs.files", "job.interface.settings"}},
+		{Message: "Name value job.interface.settings.db_pass should be normalized.", highlight: true},
+	}}
+
+	cases := []struct {
+		err      error
+		expected string
+	}{
+		{nil, "[]"},
+		{errors.New("ERROR: Malformed JSON detected.\n"), `[{"message":"ERROR: Malformed JSON detected."}]`},
+		{invalid, `[{"message":"job is required","field":"(root)","context":"(root)"},` +
+			`{"message":"Multiple Name values are assigned the same INPUT Name value. Each Name value must be unique.",` +
+			`"names":["job.interface.inputs.files","job.interface.settings"]},` +
+			`{"message":"Name value job.interface.settings.db_pass should be normalized."}]`},
+	}
+
+	for _, c := range cases {
+		data, _ := json.Marshal(ValidationErrors(c.err))
+		if result := string(data); result != c.expected {
+			t.Errorf("ValidationErrors(%v) == %v, expected %v", c.err, result, c.expected)
+		}
+	}
+
+	expected := "ERROR:seed.manifest.json is not valid. See errors:\n-ERROR job is required\n\tField: (root)\n\tContext: (root)\n" +
+		"ERROR: Multiple Name values are assigned the same INPUT Name value. Each Name value must be unique.\n" +
+		"\tjob.interface.inputs.files\n\tjob.interface.settings\n" +
+		"\033[41mERROR: Name value job.interface.settings.db_pass should be normalized.\033[0m\n"
+	if result := invalid.Error(); result != expected {
+		t.Errorf("ValidationError.Error() == %q, expected %q", result, expected)
+	}
+}
@@ -14,6 +14,7 @@ const PullCommand = "pull"
 const RunCommand = "run"
 const SearchCommand = "search"
 const ValidateCommand = "validate"
+const CollectCommand = "collect"
 const VersionCommand = "version"
 const SpecCommand = "spec"
 
@@ -149,12 +150,139 @@ const BatchFlag = "batch"
 //ShortBatchFlag - shorthand flag for batch
 const ShortBatchFlag = "b"
 
+//JobsFlag defines the number of batch rows to run at the same time
+const JobsFlag = "jobs"
+
+//ResumeFlag defines whether to skip the batch rows that succeeded in a previous run of the batch
+const ResumeFlag = "resume"
+
+//RetryFlag defines the number of times to retry a batch row that failed with a job error
+const RetryFlag = "retry"
+
+//JobErrorCategory is the category of the errors of a seed manifest caused by the job itself,
+// which may succeed if run again
+const JobErrorCategory = "job"
+
+//DataErrorCategory is the category of the errors of a seed manifest caused by the input data
+const DataErrorCategory = "data"
+
 //RepeatFlag defines how many times to run a docker image
 const RepeatFlag = "repetitions"
 
 //ShortRepeatFlag - shorthand flag for repetitions
 const ShortRepeatFlag = "rep"
 
+//TimeoutFlag defines the number of seconds a job may run before it is stopped;
+// overrides the job.timeout value of the seed manifest
+const TimeoutFlag = "timeout"
+
+//TimeoutExitCode is the exit code returned by seed when a job exceeds its timeout
+const TimeoutExitCode = 124
+
+//CancelledExitCode is the exit code returned by seed when it is interrupted (128 + SIGINT)
+const CancelledExitCode = 130
+
+//StrictFlag defines whether output validation errors should fail the run
+const StrictFlag = "strict"
+
+//StrictMediaTypesFlag defines whether input media type mismatches are reported as errors instead of warnings
+const StrictMediaTypesFlag = "strict-media-types"
+
+//FormatFlag defines the format of the results written to stdout: text, json or yaml
+const FormatFlag = "format"
+
+//TextFormat writes human readable results (the default)
+const TextFormat = "text"
+
+//JsonFormat writes results as a JSON document
+const JsonFormat = "json"
+
+//YamlFormat writes results as a YAML document
+const YamlFormat = "yaml"
+
+//CsvFormat writes the batch summary as a CSV file with one record per row
+const CsvFormat = "csv"
+
+//JunitFormat writes the batch summary as a JUnit XML report with one test case per row
+const JunitFormat = "junit"
+
+//CollectFlag defines the file seed batch writes the table of the json outputs of its rows to
+const CollectFlag = "collect"
+
+//TableFlag defines the file seed collect writes the table of the json outputs of a batch to
+const TableFlag = "table"
+
+//SummaryFlag defines the file the batch summary is written to
+const SummaryFlag = "summary"
+
+//SummaryFormatFlag defines the format of the batch summary: csv, json or junit
+const SummaryFormatFlag = "summary-format"
+
+//FailedRowsExitCode is added to the number of rows that failed to give the exit code of seed
+// batch, so that failed rows aren't mistaken for the exit code 1 of other errors
+const FailedRowsExitCode = 200
+
+//MaxFailedRowsExitCode is the highest exit code returned by seed batch when rows failed
+const MaxFailedRowsExitCode = 250
+
+//DryRunFlag defines whether to resolve and print the docker command without starting a container
+const DryRunFlag = "dry-run"
+
+//EmitScriptFlag defines the shell script to write the resolved docker commands of a dry run to
+const EmitScriptFlag = "emit-script"
+
+//ShellFlag defines whether to start an interactive shell in the job container instead of the job command
+const ShellFlag = "shell"
+
+//KeepOnFailureFlag defines whether to keep the container of a failed run for debugging
+const KeepOnFailureFlag = "keep-on-failure"
+
+//MultipleInputsDir is the directory in the container the files of multiple inputs are mounted under
+const MultipleInputsDir = "/seed/inputs"
+
+//RunAsFlag defines the user the job container runs as: host, image, root or UID[:GID]
+const RunAsFlag = "run-as"
+
+//HostUser runs the job container as the user running seed so its outputs are owned by them
+const HostUser = "host"
+
+//ImageUser runs the job container as the user set by the image
+const ImageUser = "image"
+
+//RootUser runs the job container as root
+const RootUser = "root"
+
+//RunAsRootTag is the job tag of seed manifests whose images must run as root
+const RunAsRootTag = "run-as-root"
+
+//JsonFilePrefix marks a json input value as the name of a file to read the value from
+// (-j KEY=@FILE). Any other value is the literal value of the input
+const JsonFilePrefix = "@"
+
+//ParamsFlag defines the job parameter file declaring the inputs, json inputs, settings and mounts of a run
+const ParamsFlag = "params"
+
+//ForceRunFlag defines whether to run a job the host doesn't have enough resources for
+const ForceRunFlag = "force"
+
+//DebugShell is the shell started in the job container by the shell flag
+const DebugShell = "sh"
+
+//RuntimeFlag defines the container runtime used to build, run and publish images: docker or podman
+const RuntimeFlag = "runtime"
+
+//RuntimeEnvVar names the environment variable selecting the container runtime when the runtime flag isn't given
+const RuntimeEnvVar = "SEED_RUNTIME"
+
+//DockerRuntime selects docker as the container runtime (the default)
+const DockerRuntime = "docker"
+
+//PodmanRuntime selects podman as the container runtime
+const PodmanRuntime = "podman"
+
+//PodmanAuthFileKey names the environment variable podman reads registry credentials from
+const PodmanAuthFileKey = "REGISTRY_AUTH_FILE"
+
 //VersionFlag defines version of seed spec to use
 const VersionFlag = "version"
 
@@ -164,6 +292,18 @@ const ShortVersionFlag = "v"
 //ResultsFileManifestName defines the filename for the results_manifest file
 const ResultsFileManifestName = "seed.outputs.json"
 
+//RunReportFileName defines the filename for the run report written to the job output directory
+const RunReportFileName = "seed.run.json"
+
+//StdoutLogFileName defines the filename the container stdout is saved to in the job output directory
+const StdoutLogFileName = "stdout.log"
+
+//StderrLogFileName defines the filename the container stderr is saved to in the job output directory
+const StderrLogFileName = "stderr.log"
+
+//CombinedLogFileName defines the filename of the timestamped log of both container streams
+const CombinedLogFileName = "seed.log"
+
 //ShortWarnAsErrorsFlag shorthand defines whether to treat warnings as errors
 const ShortWarnAsErrorsFlag = "w"
 
@@ -0,0 +1,234 @@
+package dockerapi
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//TarContext returns a tar archive of a build context directory. Files matching the patterns
+// in the .dockerignore file of the directory are left out. If the Dockerfile is outside of
+// the directory it is added to the archive. Returns the archive and the path of the
+// Dockerfile within it
+func TarContext(dir, dockerfile string) (io.ReadCloser, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if dockerfile == "" {
+		dockerfile = filepath.Join(dir, "Dockerfile")
+	}
+	dockerfile, err = filepath.Abs(dockerfile)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = os.Stat(dockerfile); err != nil {
+		return nil, "", fmt.Errorf("ERROR: Dockerfile not found. %s\n", err.Error())
+	}
+
+	ignore, err := ReadDockerignore(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the daemon always needs the Dockerfile, even if .dockerignore excludes it
+	dockerfileName, err := filepath.Rel(dir, dockerfile)
+	external := err != nil || strings.HasPrefix(dockerfileName, "..")
+	if external {
+		dockerfileName = fmt.Sprintf(".dockerfile.%d", time.Now().UnixNano())
+	}
+	dockerfileName = filepath.ToSlash(dockerfileName)
+
+	r, w := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(w)
+		err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil || rel == "." {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != dockerfileName && ignore.Excludes(rel) {
+				if info.IsDir() && !ignore.HasExceptions() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return addToTar(tw, file, rel, info)
+		})
+		if err == nil && external {
+			var info os.FileInfo
+			if info, err = os.Stat(dockerfile); err == nil {
+				err = addToTar(tw, dockerfile, dockerfileName, info)
+			}
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		w.CloseWithError(err)
+	}()
+	return r, dockerfileName, nil
+}
+
+func addToTar(tw *tar.Writer, file, name string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(file); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+//Dockerignore holds the patterns of a .dockerignore file
+type Dockerignore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	regexp    *regexp.Regexp
+	exception bool
+}
+
+//ReadDockerignore reads the patterns of a .dockerignore file. A missing file excludes nothing
+func ReadDockerignore(fileName string) (*Dockerignore, error) {
+	ignore := &Dockerignore{}
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return ignore, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("ERROR: Unable to read %s: %s\n", fileName, err.Error())
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	return NewDockerignore(patterns), nil
+}
+
+//NewDockerignore returns a Dockerignore for the given patterns. Patterns use the syntax of
+// filepath.Match, with ** matching any number of directories. Patterns starting with !
+// are exceptions that include files excluded by an earlier pattern
+func NewDockerignore(patterns []string) *Dockerignore {
+	ignore := &Dockerignore{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		exception := strings.HasPrefix(p, "!")
+		if exception {
+			p = strings.TrimSpace(p[1:])
+		}
+		p = strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "/")
+		if re, err := regexp.Compile(patternRegexp(p)); err == nil {
+			ignore.patterns = append(ignore.patterns, ignorePattern{re, exception})
+		}
+	}
+	return ignore
+}
+
+//Excludes returns true if the file with the given slash separated path relative to the build
+// context is excluded. A file is excluded if it or one of its parent directories matches;
+// the last matching pattern wins
+func (d *Dockerignore) Excludes(file string) bool {
+	excluded := false
+	for _, p := range d.patterns {
+		match := false
+		for f := file; f != "." && f != "/" && !match; f = path.Dir(f) {
+			match = p.regexp.MatchString(f)
+		}
+		if match {
+			excluded = !p.exception
+		}
+	}
+	return excluded
+}
+
+//HasExceptions returns true if any pattern is an exception
+func (d *Dockerignore) HasExceptions() bool {
+	for _, p := range d.patterns {
+		if p.exception {
+			return true
+		}
+	}
+	return false
+}
+
+// patternRegexp converts a .dockerignore pattern into a regular expression
+func patternRegexp(pattern string) string {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				// **/ matches zero or more directories
+				i++
+				re.WriteString("(.*/)?")
+			} else {
+				re.WriteString(".*")
+			}
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			re.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return re.String()
+}
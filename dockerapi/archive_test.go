@@ -0,0 +1,31 @@
+package dockerapi
+
+import (
+	"testing"
+)
+
+func TestDockerignore(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		file     string
+		expected bool
+	}{
+		{[]string{"*.md"}, "README.md", true},
+		{[]string{"*.md"}, "docs/README.md", false},
+		{[]string{"**/*.md"}, "docs/README.md", true},
+		{[]string{"**/*.md"}, "README.md", true},
+		{[]string{"output*"}, "output-job/seed.run.json", true},
+		{[]string{"/temp?"}, "temp1", true},
+		{[]string{"*.md", "!README.md"}, "README.md", false},
+		{[]string{"*.md", "!README.md"}, "CHANGES.md", true},
+		{[]string{"# comment", "", "data/[a-c]*"}, "data/b.txt", true},
+		{[]string{"data/[!a-c]*"}, "data/b.txt", false},
+	}
+
+	for _, c := range cases {
+		result := NewDockerignore(c.patterns).Excludes(c.file)
+		if result != c.expected {
+			t.Errorf("Dockerignore(%q).Excludes(%q) == %v, expected %v", c.patterns, c.file, result, c.expected)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+//Package dockerapi is a minimal client for the Docker Engine API. It covers the operations
+// seed needs (image inspect, build, tag, push, pull and remove, and running containers
+// and reading their logs) without shelling out to the docker command line
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+//DefaultHost is the address of the Docker Engine API when DOCKER_HOST is not set
+const DefaultHost = "unix:///var/run/docker.sock"
+
+//HostEnvVar names the environment variable holding the address of the Docker Engine API
+const HostEnvVar = "DOCKER_HOST"
+
+//Client sends requests to the Docker Engine API listening on a unix socket or tcp address
+type Client struct {
+	Host    string
+	network string
+	address string
+	http    *http.Client
+}
+
+//Error is an error response returned by the Docker Engine API
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ERROR: Docker Engine API returned %d: %s", e.StatusCode, e.Message)
+}
+
+//IsNotFound returns true if err is a Docker Engine API error for a missing image or container
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+//NewClientFromEnv returns a client for the Docker Engine API named by DOCKER_HOST, or the
+// default unix socket if it is not set. TLS, ssh and named pipe hosts are not supported
+func NewClientFromEnv() (*Client, error) {
+	host := os.Getenv(HostEnvVar)
+	if host == "" {
+		if runtime.GOOS == "windows" {
+			return nil, errors.New("ERROR: The Docker Engine API named pipe is not supported.")
+		}
+		host = DefaultHost
+	}
+	if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		return nil, errors.New("ERROR: TLS connections to the Docker Engine API are not supported.")
+	}
+	return NewClient(host)
+}
+
+//NewClient returns a client for the Docker Engine API at the given unix:// or tcp:// address
+func NewClient(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Invalid Docker host %s: %s\n", host, err.Error())
+	}
+
+	c := &Client{Host: host}
+	switch u.Scheme {
+	case "unix":
+		c.network, c.address = "unix", u.Path
+	case "tcp", "http":
+		c.network, c.address = "tcp", u.Host
+	default:
+		return nil, fmt.Errorf("ERROR: Unsupported Docker host %s. Only unix:// and tcp:// hosts are supported.\n", host)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, c.network, c.address)
+		},
+	}
+	c.http = &http.Client{Transport: transport}
+	return c, nil
+}
+
+//Reachable returns true if a connection can be opened to the Docker Engine API. No request
+// is sent, so this is much cheaper than running docker info
+func (c *Client) Reachable() bool {
+	conn, err := net.DialTimeout(c.network, c.address, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+//Ping returns an error if the Docker Engine API doesn't respond
+func (c *Client) Ping() error {
+	resp, err := c.do("GET", "/_ping", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+//SystemInfo is the subset of the system information returned by the Docker Engine API used by seed
+type SystemInfo struct {
+	Driver          string `json:"Driver"`
+	NCPU            int    `json:"NCPU"`
+	MemTotal        int64  `json:"MemTotal"`
+	OperatingSystem string `json:"OperatingSystem"`
+	ServerVersion   string `json:"ServerVersion"`
+}
+
+//Info returns information about the docker host
+func (c *Client) Info() (*SystemInfo, error) {
+	var info SystemInfo
+	if err := c.doJSON("GET", "/info", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// do sends a request to the API and returns the response. Responses with an error status
+// are closed and returned as an *Error
+func (c *Client) do(method, path string, query url.Values, headers map[string]string, body io.Reader) (*http.Response, error) {
+	u := "http://docker" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Cannot connect to the Docker Engine API at %s: %s\n", c.Host, err.Error())
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// doJSON sends v encoded as json and decodes the response into result if it isn't nil
+func (c *Client) doJSON(method, path string, query url.Values, v, result interface{}) error {
+	var body io.Reader
+	headers := map[string]string{}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		headers["Content-Type"] = "application/json"
+	}
+
+	resp, err := c.do(method, path, query, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result != nil {
+		if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("ERROR: Unable to decode Docker Engine API response to %s %s: %s\n", method, path, err.Error())
+		}
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	data, _ := ioutil.ReadAll(resp.Body)
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Message == "" {
+		msg.Message = strings.TrimSpace(string(data))
+	}
+	if msg.Message == "" {
+		msg.Message = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: msg.Message}
+}
+
+// message is an entry of the json message stream returned by build, pull and push
+type message struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	ID          string `json:"id"`
+	Progress    string `json:"progress"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// readMessages writes the build output and status updates of a json message stream to out,
+// skipping progress updates, and returns the error reported in the stream if there is one.
+// Errors in the stream are reported with a 200 status so they are easy to miss
+func readMessages(body io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var m message
+		if err := decoder.Decode(&m); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("ERROR: Unable to read Docker Engine API output: %s\n", err.Error())
+		}
+
+		if m.Error != "" || m.ErrorDetail.Message != "" {
+			if m.ErrorDetail.Message != "" {
+				m.Error = m.ErrorDetail.Message
+			}
+			return &Error{StatusCode: http.StatusOK, Message: m.Error}
+		}
+		if out == nil {
+			continue
+		}
+		if m.Stream != "" {
+			io.WriteString(out, m.Stream)
+		} else if m.Status != "" && m.Progress == "" {
+			if m.ID != "" {
+				fmt.Fprintf(out, "%s: %s\n", m.ID, m.Status)
+			} else {
+				fmt.Fprintf(out, "%s\n", m.Status)
+			}
+		}
+	}
+}
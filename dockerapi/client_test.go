@@ -0,0 +1,269 @@
+package dockerapi
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeEngine is a stand-in for the Docker Engine API that records the requests it receives
+type fakeEngine struct {
+	server     *httptest.Server
+	dir        string
+	requests   []string
+	created    createRequest
+	auth       string
+	buildFiles []string
+	buildQuery string
+}
+
+// newFakeEngine starts a fake API server listening on a unix socket. Returns nil if unix
+// sockets aren't available
+func newFakeEngine(t *testing.T) *fakeEngine {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	dir, _ := ioutil.TempDir("", "seed-engine-")
+	listener, err := net.Listen("unix", filepath.Join(dir, "docker.sock"))
+	if err != nil {
+		t.Errorf("Unable to listen on unix socket: %v", err)
+		return nil
+	}
+
+	f := &fakeEngine{dir: dir}
+	f.server = httptest.NewUnstartedServer(http.HandlerFunc(f.serve))
+	f.server.Listener = listener
+	f.server.Start()
+	return f
+}
+
+func (f *fakeEngine) close() {
+	f.server.Close()
+	os.RemoveAll(f.dir)
+}
+
+func (f *fakeEngine) client() *Client {
+	c, _ := NewClient("unix://" + filepath.Join(f.dir, "docker.sock"))
+	return c
+}
+
+func (f *fakeEngine) serve(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	writeJSON := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	switch {
+	case r.URL.Path == "/images/my-job-0.1.0-seed:1.0.0/json":
+		writeJSON(200, map[string]interface{}{"Id": "sha256:abc",
+			"Config": map[string]interface{}{"Labels": map[string]string{"com.ngageoint.seed.manifest": "{}"}}})
+	case strings.HasSuffix(r.URL.Path, "/json") && strings.HasPrefix(r.URL.Path, "/images/"):
+		writeJSON(404, map[string]string{"message": "No such image: " + r.URL.Path[8:len(r.URL.Path)-5]})
+	case r.URL.Path == "/images/my-job-0.1.0-seed:1.0.0/tag":
+		w.WriteHeader(201)
+	case r.URL.Path == "/images/create":
+		f.auth = r.Header.Get("X-Registry-Auth")
+		io.WriteString(w, `{"status":"Pulling from library/alpine","id":"3.8"}`+"\n")
+		if r.URL.Query().Get("fromImage") == "missing" {
+			io.WriteString(w, `{"errorDetail":{"message":"manifest for missing:latest not found"},"error":"manifest for missing:latest not found"}`+"\n")
+		}
+	case r.URL.Path == "/build":
+		f.buildQuery = r.URL.RawQuery
+		tr := tar.NewReader(r.Body)
+		for {
+			h, err := tr.Next()
+			if err != nil {
+				break
+			}
+			f.buildFiles = append(f.buildFiles, h.Name)
+		}
+		io.WriteString(w, `{"stream":"Step 1/2 : FROM alpine\n"}`+"\n")
+		if strings.Contains(r.URL.RawQuery, "broken") {
+			io.WriteString(w, `{"errorDetail":{"code":1,"message":"The command '/bin/sh -c exit 1' returned a non-zero code: 1"}}`+"\n")
+		}
+	case r.URL.Path == "/containers/create":
+		json.NewDecoder(r.Body).Decode(&f.created)
+		writeJSON(201, map[string]string{"Id": "c0ffee"})
+	case r.URL.Path == "/containers/c0ffee/start":
+		w.WriteHeader(204)
+	case r.URL.Path == "/containers/c0ffee/logs":
+		writeFrame(w, 1, "3\n")
+		writeFrame(w, 2, "warning: adding numbers\n")
+	case r.URL.Path == "/containers/c0ffee/wait":
+		writeJSON(200, map[string]interface{}{"StatusCode": 3})
+	case r.URL.Path == "/containers/c0ffee" && r.Method == "DELETE":
+		w.WriteHeader(204)
+	default:
+		writeJSON(500, map[string]string{"message": "unexpected request " + r.Method + " " + r.URL.Path})
+	}
+}
+
+func writeFrame(w io.Writer, stream byte, data string) {
+	header := []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	w.Write(header)
+	io.WriteString(w, data)
+}
+
+func TestNewClient(t *testing.T) {
+	cases := []struct {
+		host    string
+		network string
+		address string
+		success bool
+	}{
+		{"unix:///var/run/docker.sock", "unix", "/var/run/docker.sock", true},
+		{"tcp://127.0.0.1:2375", "tcp", "127.0.0.1:2375", true},
+		{"ssh://user@host", "", "", false},
+		{"npipe:////./pipe/docker_engine", "", "", false},
+	}
+
+	for _, c := range cases {
+		client, err := NewClient(c.host)
+		if c.success != (err == nil) {
+			t.Errorf("NewClient(%q) == %v, expected success %v", c.host, err, c.success)
+		}
+		if err == nil && (client.network != c.network || client.address != c.address) {
+			t.Errorf("NewClient(%q) == %v %v, expected %v %v", c.host, client.network, client.address, c.network, c.address)
+		}
+	}
+}
+
+func TestImageInspect(t *testing.T) {
+	engine := newFakeEngine(t)
+	if engine == nil {
+		return
+	}
+	defer engine.close()
+	client := engine.client()
+
+	if !client.Reachable() {
+		t.Errorf("Reachable() == false, expected true")
+	}
+
+	info, err := client.ImageInspect("my-job-0.1.0-seed:1.0.0")
+	if err != nil || info.Config.Labels["com.ngageoint.seed.manifest"] != "{}" {
+		t.Errorf("ImageInspect(%q) == %v, %v, expected the manifest label", "my-job-0.1.0-seed:1.0.0", info, err)
+	}
+
+	_, err = client.ImageInspect("missing:1.0.0")
+	if !IsNotFound(err) {
+		t.Errorf("ImageInspect(%q) == %v, expected a not found error", "missing:1.0.0", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "No such image: missing:1.0.0") {
+		t.Errorf("ImageInspect(%q) == %v, expected the message from the API", "missing:1.0.0", err)
+	}
+
+	if err = client.ImageTag("my-job-0.1.0-seed:1.0.0", "localhost:5000/my-job-0.1.0-seed:1.0.0"); err != nil {
+		t.Errorf("ImageTag() == %v, expected nil", err)
+	}
+}
+
+func TestImagePull(t *testing.T) {
+	engine := newFakeEngine(t)
+	if engine == nil {
+		return
+	}
+	defer engine.close()
+	client := engine.client()
+
+	var out bytes.Buffer
+	auth := &AuthConfig{Username: "user", Password: "pass", ServerAddress: "localhost:5000"}
+	if err := client.ImagePull("alpine:3.8", auth, &out); err != nil {
+		t.Errorf("ImagePull(%q) == %v, expected nil", "alpine:3.8", err)
+	}
+	if out.String() != "3.8: Pulling from library/alpine\n" {
+		t.Errorf("ImagePull(%q) output == %q, expected the status", "alpine:3.8", out.String())
+	}
+	decoded, _ := base64.URLEncoding.DecodeString(engine.auth)
+	if string(decoded) != `{"username":"user","password":"pass","serveraddress":"localhost:5000"}` {
+		t.Errorf("ImagePull(%q) sent X-Registry-Auth %s, expected the credentials", "alpine:3.8", decoded)
+	}
+
+	// errors are reported in the message stream with a 200 status
+	err := client.ImagePull("missing", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "manifest for missing:latest not found") {
+		t.Errorf("ImagePull(%q) == %v, expected the error from the stream", "missing", err)
+	}
+}
+
+func TestImageBuild(t *testing.T) {
+	engine := newFakeEngine(t)
+	if engine == nil {
+		return
+	}
+	defer engine.close()
+	client := engine.client()
+
+	context, dockerfile, err := TarContext("../examples/addition-job", "")
+	if err != nil {
+		t.Errorf("TarContext() == %v, expected nil", err)
+		return
+	}
+	var out bytes.Buffer
+	err = client.ImageBuild(context, BuildOptions{Dockerfile: dockerfile, Tags: []string{"addition-job-0.0.1-seed:1.0.0"}}, &out)
+	context.Close()
+	if err != nil {
+		t.Errorf("ImageBuild() == %v, expected nil", err)
+	}
+	if out.String() != "Step 1/2 : FROM alpine\n" {
+		t.Errorf("ImageBuild() output == %q, expected the build steps", out.String())
+	}
+	files := strings.Join(engine.buildFiles, " ")
+	if !strings.Contains(files, "Dockerfile") || !strings.Contains(files, "seed.manifest.json") {
+		t.Errorf("ImageBuild() sent %v, expected the job directory", files)
+	}
+
+	context, dockerfile, _ = TarContext("../examples/addition-job", "")
+	err = client.ImageBuild(context, BuildOptions{Dockerfile: dockerfile, Tags: []string{"broken"}}, nil)
+	context.Close()
+	if err == nil || !strings.Contains(err.Error(), "returned a non-zero code: 1") {
+		t.Errorf("ImageBuild() == %v, expected the failed step", err)
+	}
+}
+
+func TestContainerRun(t *testing.T) {
+	engine := newFakeEngine(t)
+	if engine == nil {
+		return
+	}
+	defer engine.close()
+	client := engine.client()
+
+	config := RunConfig{Name: "seed-addition-job-1", Remove: true,
+		Config: ContainerConfig{Image: "addition-job-0.0.1-seed:1.0.0", Cmd: []string{"add", "1", "2"}, Env: []string{"OUTPUT_DIR=/data/out"}},
+		Host:   HostConfig{Binds: []string{"/data:/data"}, Memory: 16 * 1024 * 1024}}
+
+	var stdout, stderr bytes.Buffer
+	code, err := client.ContainerRun(config, &stdout, &stderr)
+	if err != nil || code != 3 {
+		t.Errorf("ContainerRun() == %v, %v, expected %v, %v", code, err, 3, nil)
+	}
+	if stdout.String() != "3\n" || stderr.String() != "warning: adding numbers\n" {
+		t.Errorf("ContainerRun() output == %q, %q, expected the demultiplexed logs", stdout.String(), stderr.String())
+	}
+	if engine.created.Image != "addition-job-0.0.1-seed:1.0.0" || engine.created.HostConfig.Memory != 16*1024*1024 ||
+		strings.Join(engine.created.Cmd, " ") != "add 1 2" {
+		t.Errorf("ContainerRun() created %+v, expected the given configuration", engine.created)
+	}
+
+	expected := "POST /containers/create POST /containers/c0ffee/start GET /containers/c0ffee/logs " +
+		"POST /containers/c0ffee/wait DELETE /containers/c0ffee"
+	if requests := strings.Join(engine.requests, " "); requests != expected {
+		t.Errorf("ContainerRun() sent %v, expected %v", requests, expected)
+	}
+}
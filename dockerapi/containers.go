@@ -0,0 +1,148 @@
+package dockerapi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+)
+
+//ContainerConfig is the portable configuration of a container
+type ContainerConfig struct {
+	Image      string   `json:"Image"`
+	Entrypoint []string `json:"Entrypoint,omitempty"`
+	Cmd        []string `json:"Cmd,omitempty"`
+	Env        []string `json:"Env,omitempty"`
+	User       string   `json:"User,omitempty"`
+}
+
+//HostConfig is the host dependent configuration of a container
+type HostConfig struct {
+	Binds      []string          `json:"Binds,omitempty"`
+	Memory     int64             `json:"Memory,omitempty"`
+	ShmSize    int64             `json:"ShmSize,omitempty"`
+	NanoCPUs   int64             `json:"NanoCpus,omitempty"`
+	StorageOpt map[string]string `json:"StorageOpt,omitempty"`
+	Runtime    string            `json:"Runtime,omitempty"`
+}
+
+//RunConfig describes a container to run
+type RunConfig struct {
+	Name   string
+	Remove bool
+	Config ContainerConfig
+	Host   HostConfig
+}
+
+type createRequest struct {
+	ContainerConfig
+	HostConfig HostConfig `json:"HostConfig"`
+}
+
+//ContainerCreate creates a container and returns its id
+func (c *Client) ContainerCreate(name string, config ContainerConfig, host HostConfig) (string, error) {
+	var query url.Values
+	if name != "" {
+		query = url.Values{"name": {name}}
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := c.doJSON("POST", "/containers/create", query, &createRequest{config, host}, &created)
+	return created.ID, err
+}
+
+//ContainerStart starts a created container
+func (c *Client) ContainerStart(id string) error {
+	return c.doJSON("POST", "/containers/"+id+"/start", nil, nil, nil)
+}
+
+//ContainerStop stops a running container. Stopping a container that isn't running succeeds
+func (c *Client) ContainerStop(id string) error {
+	return c.doJSON("POST", "/containers/"+id+"/stop", nil, nil, nil)
+}
+
+//ContainerRemove forcibly removes a container
+func (c *Client) ContainerRemove(id string) error {
+	return c.doJSON("DELETE", "/containers/"+id, url.Values{"force": {"1"}}, nil, nil)
+}
+
+//ContainerWait waits for a container to stop and returns its exit code
+func (c *Client) ContainerWait(id string) (int, error) {
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+		Error      *struct {
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	if err := c.doJSON("POST", "/containers/"+id+"/wait", nil, nil, &result); err != nil {
+		return -1, err
+	}
+	if result.Error != nil && result.Error.Message != "" {
+		return result.StatusCode, &Error{StatusCode: 200, Message: result.Error.Message}
+	}
+	return result.StatusCode, nil
+}
+
+//ContainerLogs copies the stdout and stderr of a container to the given writers. If follow
+// is true, it returns once the container stops
+func (c *Client) ContainerLogs(id string, follow bool, stdout, stderr io.Writer) error {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if follow {
+		query.Set("follow", "1")
+	}
+	resp, err := c.do("GET", "/containers/"+id+"/logs", query, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return Demultiplex(resp.Body, stdout, stderr)
+}
+
+//ContainerRun creates and starts a container, copies its output to stdout and stderr and
+// returns its exit code once it stops. The container is removed afterwards if config.Remove
+// is set. The output is read from the container logs rather than an attached stream so
+// nothing is lost if the container exits before the logs are requested
+func (c *Client) ContainerRun(config RunConfig, stdout, stderr io.Writer) (int, error) {
+	id, err := c.ContainerCreate(config.Name, config.Config, config.Host)
+	if err != nil {
+		return -1, err
+	}
+	if config.Remove {
+		defer c.ContainerRemove(id)
+	}
+
+	if err = c.ContainerStart(id); err != nil {
+		return -1, err
+	}
+	if err = c.ContainerLogs(id, true, stdout, stderr); err != nil {
+		return -1, err
+	}
+	return c.ContainerWait(id)
+}
+
+//Demultiplex splits the stream returned by the logs of a container without a tty into
+// stdout and stderr. Each frame has an 8 byte header holding the stream and frame size
+func Demultiplex(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("ERROR: Unable to read container output: %s\n", err.Error())
+		}
+
+		out := stdout
+		if header[0] == 2 {
+			out = stderr
+		}
+		if out == nil {
+			out = ioutil.Discard
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(out, r, size); err != nil {
+			return fmt.Errorf("ERROR: Unable to read container output: %s\n", err.Error())
+		}
+	}
+}
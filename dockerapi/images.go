@@ -0,0 +1,178 @@
+package dockerapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//DockerHubAddress is the server address docker uses for Docker Hub credentials
+const DockerHubAddress = "https://index.docker.io/v1/"
+
+//AuthConfig holds the credentials used to log in to a registry
+type AuthConfig struct {
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	ServerAddress string `json:"serveraddress"`
+}
+
+//ImageInfo is the subset of the image inspect response used by seed
+type ImageInfo struct {
+	ID       string   `json:"Id"`
+	RepoTags []string `json:"RepoTags"`
+	Size     int64    `json:"Size"`
+	Config   struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+//BuildOptions describes an image build
+type BuildOptions struct {
+	// Dockerfile is the path of the Dockerfile within the build context
+	Dockerfile string
+	Tags       []string
+	CacheFrom  []string
+	Labels     map[string]string
+	// Auths holds credentials for registries base images are pulled from
+	Auths []AuthConfig
+}
+
+//ImageInspect returns information about a local image. A missing image returns an error
+// for which IsNotFound is true
+func (c *Client) ImageInspect(image string) (*ImageInfo, error) {
+	var info ImageInfo
+	if err := c.doJSON("GET", "/images/"+image+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+//ImageTag tags a local image with a new reference
+func (c *Client) ImageTag(image, ref string) error {
+	repo, tag := SplitReference(ref)
+	query := url.Values{"repo": {repo}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	return c.doJSON("POST", "/images/"+image+"/tag", query, nil, nil)
+}
+
+//ImageRemove removes a local image
+func (c *Client) ImageRemove(image string) error {
+	return c.doJSON("DELETE", "/images/"+image, nil, nil, nil)
+}
+
+//ImagePull pulls an image from its registry, writing status updates to out
+func (c *Client) ImagePull(image string, auth *AuthConfig, out io.Writer) error {
+	repo, tag := SplitReference(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	query := url.Values{"fromImage": {repo}, "tag": {tag}}
+	resp, err := c.do("POST", "/images/create", query, authHeader(auth), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readMessages(resp.Body, out)
+}
+
+//ImagePush pushes an image to its registry, writing status updates to out
+func (c *Client) ImagePush(image string, auth *AuthConfig, out io.Writer) error {
+	repo, tag := SplitReference(image)
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	resp, err := c.do("POST", "/images/"+repo+"/push", query, authHeader(auth), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readMessages(resp.Body, out)
+}
+
+//ImageBuild builds an image from a tar archive of the build context, writing the build
+// output to out. A failed build step returns an error
+func (c *Client) ImageBuild(context io.Reader, opts BuildOptions, out io.Writer) error {
+	query := url.Values{"rm": {"1"}}
+	for _, t := range opts.Tags {
+		query.Add("t", t)
+	}
+	if opts.Dockerfile != "" {
+		query.Set("dockerfile", opts.Dockerfile)
+	}
+	if len(opts.CacheFrom) > 0 {
+		cacheFrom, _ := json.Marshal(opts.CacheFrom)
+		query.Set("cachefrom", string(cacheFrom))
+	}
+	if len(opts.Labels) > 0 {
+		labels, _ := json.Marshal(opts.Labels)
+		query.Set("labels", string(labels))
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-tar"}
+	if len(opts.Auths) > 0 {
+		auths := map[string]AuthConfig{}
+		for _, a := range opts.Auths {
+			auths[a.ServerAddress] = a
+		}
+		config, _ := json.Marshal(auths)
+		headers["X-Registry-Config"] = base64.URLEncoding.EncodeToString(config)
+	}
+
+	resp, err := c.do("POST", "/build", query, headers, context)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readMessages(resp.Body, out)
+}
+
+//Login checks the credentials with the registry. The daemon doesn't store them; they must
+// be passed to each pull, push or build
+func (c *Client) Login(auth AuthConfig) error {
+	return c.doJSON("POST", "/auth", nil, &auth, nil)
+}
+
+//SplitReference splits an image reference into its repository and tag. The tag is empty
+// if the reference doesn't have one
+func SplitReference(ref string) (string, string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ""
+	}
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+//RegistryOf returns the registry an image reference is pulled from, or docker.io for
+// images on Docker Hub
+func RegistryOf(ref string) string {
+	i := strings.Index(ref, "/")
+	if i < 0 {
+		return "docker.io"
+	}
+	domain := ref[:i]
+	if !strings.ContainsAny(domain, ".:") && domain != "localhost" {
+		return "docker.io"
+	}
+	if domain == "index.docker.io" || domain == "registry-1.docker.io" {
+		return "docker.io"
+	}
+	return domain
+}
+
+// authHeader returns the X-Registry-Auth header for the credentials. The daemon requires
+// the header for pushes even when no credentials are needed
+func authHeader(auth *AuthConfig) map[string]string {
+	config := []byte("{}")
+	if auth != nil {
+		config, _ = json.Marshal(auth)
+	}
+	return map[string]string{"X-Registry-Auth": base64.URLEncoding.EncodeToString(config)}
+}
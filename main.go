@@ -68,14 +68,17 @@ import (
 	"strconv"
 
 	"github.com/ngageoint/seed-cli/assets"
+	"github.com/ngageoint/seed-cli/cliutil"
 	"github.com/ngageoint/seed-cli/commands"
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/remote"
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 	"github.com/zyxar/image2ascii/ascii"
 )
 
 var batchCmd *flag.FlagSet
+var collectCmd *flag.FlagSet
 var buildCmd *flag.FlagSet
 var initCmd *flag.FlagSet
 var listCmd *flag.FlagSet
@@ -95,6 +98,10 @@ func main() {
 	// before program exit.
 	defer util.HandleExit()
 
+	// Interrupted runs stop their container and write their report before seed exits
+	cliutil.HandleInterrupts()
+	remote.Context = cliutil.Interrupted()
+
 	// Parse input flags
 	DefineFlags()
 
@@ -116,7 +123,8 @@ func main() {
 		schemaFile := validateCmd.Lookup(constants.SchemaFlag).Value.String()
 		dir := validateCmd.Lookup(constants.JobDirectoryFlag).Value.String()
 		version := validateCmd.Lookup(constants.VersionFlag).Value.String()
-		err := commands.Validate(warningFlag, schemaFile, dir, version)
+		format := formatFlag(validateCmd)
+		err := commands.Validate(warningFlag, schemaFile, dir, version, format)
 		if err != nil {
 			util.PrintUtil("%s\n", err.Error())
 			panic(util.Exit{1})
@@ -131,19 +139,17 @@ func main() {
 		filter := searchCmd.Lookup(constants.FilterFlag).Value.String()
 		username := searchCmd.Lookup(constants.UserFlag).Value.String()
 		password := searchCmd.Lookup(constants.PassFlag).Value.String()
+		format := formatFlag(searchCmd)
 		results, err := commands.DockerSearch(url, org, filter, username, password)
 		if err != nil {
 			util.PrintUtil("%s\n", err.Error())
 			panic(util.Exit{1})
 		}
 
-		if len(results) > 0 {
-			util.PrintUtil("Found %v Repositories:\n", len(results))
-			for _, r := range results {
-				util.PrintUtil("%s\n", r)
-			}
-		} else {
-			util.PrintUtil("No repositories found.\n")
+		err = commands.PrintSearchResults(url, org, results, format)
+		if err != nil {
+			util.PrintUtil("%s\n", err.Error())
+			panic(util.Exit{1})
 		}
 		panic(util.Exit{0})
 	}
@@ -172,7 +178,7 @@ func main() {
 
 	// seed list: Lists all seed compliant images on (default) local machine
 	if listCmd.Parsed() {
-		_, err := commands.DockerList()
+		_, err := commands.DockerList(formatFlag(listCmd))
 		if err != nil {
 			util.PrintUtil("%s\n", err.Error())
 			panic(util.Exit{1})
@@ -226,12 +232,72 @@ func main() {
 		batchFile := batchCmd.Lookup(constants.BatchFlag).Value.String()
 		imageName := batchCmd.Lookup(constants.ImgNameFlag).Value.String()
 		manifest := batchCmd.Lookup(constants.ManifestFlag).Value.String()
-		settings := strings.Split(batchCmd.Lookup(constants.SettingFlag).Value.String(), ",")
-		mounts := strings.Split(batchCmd.Lookup(constants.MountFlag).Value.String(), ",")
+		settings := arrayFlag(batchCmd, constants.SettingFlag)
+		mounts := arrayFlag(batchCmd, constants.MountFlag)
 		outputDir := batchCmd.Lookup(constants.JobOutputDirFlag).Value.String()
 		rmFlag := batchCmd.Lookup(constants.RmFlag).Value.String() == constants.TrueString
 		metadataSchema := batchCmd.Lookup(constants.SchemaFlag).Value.String()
-		err := commands.BatchRun(batchDir, batchFile, imageName, manifest, outputDir, metadataSchema, settings, mounts, rmFlag)
+		timeout, err := strconv.Atoi(batchCmd.Lookup(constants.TimeoutFlag).Value.String())
+		if err != nil {
+			util.PrintUtil("Error reading timeout flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		strict := batchCmd.Lookup(constants.StrictFlag).Value.String() == constants.TrueString
+		strictMediaTypes := batchCmd.Lookup(constants.StrictMediaTypesFlag).Value.String() == constants.TrueString
+		format := formatFlag(batchCmd)
+		dryRun := batchCmd.Lookup(constants.DryRunFlag).Value.String() == constants.TrueString
+		force := batchCmd.Lookup(constants.ForceRunFlag).Value.String() == constants.TrueString
+		runAs := batchCmd.Lookup(constants.RunAsFlag).Value.String()
+		jobs, err := strconv.Atoi(batchCmd.Lookup(constants.JobsFlag).Value.String())
+		if err != nil || jobs < 1 {
+			util.PrintUtil("ERROR: Invalid -%s value %s; expected a number of rows greater than zero\n",
+				constants.JobsFlag, batchCmd.Lookup(constants.JobsFlag).Value.String())
+			panic(util.Exit{1})
+		}
+		resume := batchCmd.Lookup(constants.ResumeFlag).Value.String() == constants.TrueString
+		retries, err := strconv.Atoi(batchCmd.Lookup(constants.RetryFlag).Value.String())
+		if err != nil || retries < 0 {
+			util.PrintUtil("ERROR: Invalid -%s value %s; expected a number of retries\n",
+				constants.RetryFlag, batchCmd.Lookup(constants.RetryFlag).Value.String())
+			panic(util.Exit{1})
+		}
+		summary := batchCmd.Lookup(constants.SummaryFlag).Value.String()
+		summaryFormat := batchCmd.Lookup(constants.SummaryFormatFlag).Value.String()
+		collect := batchCmd.Lookup(constants.CollectFlag).Value.String()
+		if err := commands.CheckSummaryFormat(summaryFormat); err != nil {
+			util.PrintUtil("%s", err.Error())
+			panic(util.Exit{1})
+		}
+		script := emitScript(batchCmd)
+		if script != nil {
+			defer script.Close()
+		}
+		run := commands.RunOptions{ImageName: imageName, Manifest: manifest, OutputDir: outputDir, MetadataSchema: metadataSchema,
+			Settings: settings, Mounts: mounts, Remove: rmFlag, Timeout: timeout, Strict: strict, StrictMediaTypes: strictMediaTypes,
+			Format: format, DryRun: dryRun, Script: script, Force: force, RunAs: runAs}
+		err = commands.BatchRun(commands.BatchOptions{RunOptions: run, BatchDir: batchDir, BatchFile: batchFile, Jobs: jobs,
+			Resume: resume, Retries: retries, Summary: summary, SummaryFormat: summaryFormat, Collect: collect})
+		if failed, ok := err.(*commands.BatchFailedError); ok {
+			util.PrintUtil("%s\n", err.Error())
+			panic(util.Exit{failed.ExitCode()})
+		} else if err == commands.ErrCancelled {
+			util.PrintUtil("%s\n", err.Error())
+			panic(util.Exit{constants.CancelledExitCode})
+		} else if err != nil {
+			util.PrintUtil("%s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed collect: Collects the json outputs of the rows of a batch into one table
+	if collectCmd.Parsed() {
+		imageName := collectCmd.Lookup(constants.ImgNameFlag).Value.String()
+		manifest := collectCmd.Lookup(constants.ManifestFlag).Value.String()
+		outputDir := collectCmd.Lookup(constants.JobOutputDirFlag).Value.String()
+		format := collectCmd.Lookup(constants.FormatFlag).Value.String()
+		table := collectCmd.Lookup(constants.TableFlag).Value.String()
+		err := commands.Collect(imageName, manifest, outputDir, format, table)
 		if err != nil {
 			util.PrintUtil("%s\n", err.Error())
 			panic(util.Exit{1})
@@ -243,14 +309,23 @@ func main() {
 	if runCmd.Parsed() {
 		imageName := runCmd.Lookup(constants.ImgNameFlag).Value.String()
 		manifest := runCmd.Lookup(constants.ManifestFlag).Value.String()
-		inputs := strings.Split(runCmd.Lookup(constants.InputsFlag).Value.String(), ",")
-		json := strings.Split(runCmd.Lookup(constants.JsonFlag).Value.String(), ",")
-		settings := strings.Split(runCmd.Lookup(constants.SettingFlag).Value.String(), ",")
-		mounts := strings.Split(runCmd.Lookup(constants.MountFlag).Value.String(), ",")
+		inputs := arrayFlag(runCmd, constants.InputsFlag)
+		json := arrayFlag(runCmd, constants.JsonFlag)
+		settings := arrayFlag(runCmd, constants.SettingFlag)
+		mounts := arrayFlag(runCmd, constants.MountFlag)
+		params := runCmd.Lookup(constants.ParamsFlag).Value.String()
 		outputDir := runCmd.Lookup(constants.JobOutputDirFlag).Value.String()
 		rmFlag := runCmd.Lookup(constants.RmFlag).Value.String() == constants.TrueString
 		quiet := runCmd.Lookup(constants.QuietFlag).Value.String() == constants.TrueString
 		metadataSchema := runCmd.Lookup(constants.SchemaFlag).Value.String()
+		strict := runCmd.Lookup(constants.StrictFlag).Value.String() == constants.TrueString
+		strictMediaTypes := runCmd.Lookup(constants.StrictMediaTypesFlag).Value.String() == constants.TrueString
+		format := formatFlag(runCmd)
+		dryRun := runCmd.Lookup(constants.DryRunFlag).Value.String() == constants.TrueString
+		shell := runCmd.Lookup(constants.ShellFlag).Value.String() == constants.TrueString
+		keepOnFailure := runCmd.Lookup(constants.KeepOnFailureFlag).Value.String() == constants.TrueString
+		force := runCmd.Lookup(constants.ForceRunFlag).Value.String() == constants.TrueString
+		runAs := runCmd.Lookup(constants.RunAsFlag).Value.String()
 
 		repeat := runCmd.Lookup(constants.RepeatFlag).Value.String()
 		reps, err := strconv.Atoi(repeat)
@@ -259,23 +334,51 @@ func main() {
 			panic(util.Exit{1})
 		}
 
+		timeout, err := strconv.Atoi(runCmd.Lookup(constants.TimeoutFlag).Value.String())
+		if err != nil {
+			util.PrintUtil("Error reading timeout flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+
+		script := emitScript(runCmd)
+		if script != nil {
+			defer script.Close()
+		}
+
+		run := commands.RunOptions{ImageName: imageName, Manifest: manifest, OutputDir: outputDir, MetadataSchema: metadataSchema,
+			Inputs: inputs, Json: json, Settings: settings, Mounts: mounts, Params: params, Remove: rmFlag, Quiet: quiet,
+			Timeout: timeout, Strict: strict, StrictMediaTypes: strictMediaTypes, Format: format, DryRun: dryRun, Script: script,
+			Shell: shell, KeepOnFailure: keepOnFailure, Force: force, RunAs: runAs}
+
 		// run for any additional repetitions
 		if reps > 1 {
 			for i := 0; i < reps; i++ {
-				outputDirRep := outputDir
+				rep := run
 				if outputDir != "" {
-					outputDirRep = outputDir + fmt.Sprintf("-%d", i)
+					rep.OutputDir = outputDir + fmt.Sprintf("-%d", i)
 				}
-				_, err := commands.DockerRun(imageName, manifest, outputDirRep, metadataSchema, inputs, json, settings, mounts, rmFlag, quiet)
-				if err != nil {
+				_, err := commands.DockerRun(rep)
+				if err == commands.ErrJobTimeout {
+					util.PrintUtil("%s\n", err.Error())
+					panic(util.Exit{constants.TimeoutExitCode})
+				} else if err == commands.ErrCancelled {
+					util.PrintUtil("%s\n", err.Error())
+					panic(util.Exit{constants.CancelledExitCode})
+				} else if err != nil {
 					util.PrintUtil("%s\n", err.Error())
 					panic(util.Exit{1})
 				}
 			}
 		} else {
 			// run once
-			_, err = commands.DockerRun(imageName, manifest, outputDir, metadataSchema, inputs, json, settings, mounts, rmFlag, quiet)
-			if err != nil {
+			_, err = commands.DockerRun(run)
+			if err == commands.ErrJobTimeout {
+				util.PrintUtil("%s\n", err.Error())
+				panic(util.Exit{constants.TimeoutExitCode})
+			} else if err == commands.ErrCancelled {
+				util.PrintUtil("%s\n", err.Error())
+				panic(util.Exit{constants.CancelledExitCode})
+			} else if err != nil {
 				util.PrintUtil("%s\n", err.Error())
 				panic(util.Exit{1})
 			}
@@ -413,6 +516,10 @@ func DefineBuildFlags() {
 	buildCmd.BoolVar(&jMaj, constants.JobVersionMajor, false,
 		"Major version bump of 'jobVersion' in manifest on disk, will auto rebuild and push")
 
+	var containerRuntime string
+	buildCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	// Print usage function
 	buildCmd.Usage = func() {
 		PrintASCIIArt()
@@ -488,6 +595,30 @@ func DefineBatchFlags() {
 	batchCmd.StringVar(&outdir, constants.ShortJobOutputDirFlag, "",
 		"Full path to the job output directory")
 
+	var jobs int
+	batchCmd.IntVar(&jobs, constants.JobsFlag, 1,
+		"Number of rows to run at the same time, limited by the cpus and memory of the host")
+
+	var resume bool
+	batchCmd.BoolVar(&resume, constants.ResumeFlag, false,
+		"Skip the rows that succeeded in the previous run of the batch writing to the same output directory")
+
+	var retries int
+	batchCmd.IntVar(&retries, constants.RetryFlag, 0,
+		"Number of times to retry a row that fails with a job error of the seed manifest")
+
+	var collect string
+	batchCmd.StringVar(&collect, constants.CollectFlag, "",
+		"File to write a table of the json outputs of the rows to: CSV, or json for files ending in .json")
+
+	var summary string
+	batchCmd.StringVar(&summary, constants.SummaryFlag, "",
+		"File to write the summary of the rows to (default is next to the output directory)")
+
+	var summaryFormat string
+	batchCmd.StringVar(&summaryFormat, constants.SummaryFormatFlag, constants.CsvFormat,
+		"Format of the summary: csv, json or junit")
+
 	var rmVar bool
 	batchCmd.BoolVar(&rmVar, constants.RmFlag, false,
 		"Specifying the -rm flag automatically removes the image after executing docker run")
@@ -498,6 +629,42 @@ func DefineBatchFlags() {
 	batchCmd.StringVar(&metadataSchema, constants.ShortSchemaFlag, "",
 		"Metadata schema file to override built in schema in validating side-car metadata files")
 
+	var timeout int
+	batchCmd.IntVar(&timeout, constants.TimeoutFlag, 0,
+		"Number of seconds each run may take before it is stopped (default is job.timeout from the seed manifest)")
+
+	var strict bool
+	batchCmd.BoolVar(&strict, constants.StrictFlag, false,
+		"Fail a run if its output does not validate against the seed manifest")
+
+	var strictMediaTypes bool
+	batchCmd.BoolVar(&strictMediaTypes, constants.StrictMediaTypesFlag, false,
+		"Fail instead of warning when input files don't match the media types declared in the seed manifest")
+
+	var format string
+	batchCmd.StringVar(&format, constants.FormatFlag, constants.TextFormat,
+		"Format of the batch results written to stdout: text, json or yaml")
+
+	var dryRun bool
+	batchCmd.BoolVar(&dryRun, constants.DryRunFlag, false,
+		"Resolve and print the docker command of each row without starting any containers")
+
+	var emitScript string
+	batchCmd.StringVar(&emitScript, constants.EmitScriptFlag, "",
+		"Shell script to write that reproduces the batch without the seed CLI")
+
+	var force bool
+	batchCmd.BoolVar(&force, constants.ForceRunFlag, false,
+		"Run each row even if the host doesn't have the cpus, memory or disk space it requires")
+
+	var runAs string
+	batchCmd.StringVar(&runAs, constants.RunAsFlag, "",
+		"User to run the job containers as: host, image, root or UID[:GID] (default is host)")
+
+	var containerRuntime string
+	batchCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	// Run usage function
 	batchCmd.Usage = func() {
 		PrintASCIIArt()
@@ -505,6 +672,46 @@ func DefineBatchFlags() {
 	}
 }
 
+//DefineCollectFlags defines the flags for the seed collect command
+func DefineCollectFlags() {
+	collectCmd = flag.NewFlagSet(constants.CollectCommand, flag.ContinueOnError)
+
+	var imgNameFlag string
+	collectCmd.StringVar(&imgNameFlag, constants.ImgNameFlag, "",
+		"Name of the Docker image the batch ran")
+	collectCmd.StringVar(&imgNameFlag, constants.ShortImgNameFlag, "",
+		"Name of the Docker image the batch ran")
+
+	var manifest string
+	collectCmd.StringVar(&manifest, constants.ManifestFlag, ".",
+		"Manifest file to use if an image name is not specified (default is seed.manifest.json in the current directory).")
+	collectCmd.StringVar(&manifest, constants.ShortManifestFlag, ".",
+		"Manifest file to use if an image name is not specified (default is seed.manifest.json in the current directory).")
+
+	var outdir string
+	collectCmd.StringVar(&outdir, constants.JobOutputDirFlag, "",
+		"Output directory of the batch")
+	collectCmd.StringVar(&outdir, constants.ShortJobOutputDirFlag, "",
+		"Output directory of the batch")
+
+	var format string
+	collectCmd.StringVar(&format, constants.FormatFlag, "",
+		"Format of the table: csv or json (default is json for -table files ending in .json, or csv)")
+
+	var table string
+	collectCmd.StringVar(&table, constants.TableFlag, "",
+		"File to write the table to (default is stdout)")
+
+	var containerRuntime string
+	collectCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
+	collectCmd.Usage = func() {
+		PrintASCIIArt()
+		commands.PrintCollectUsage()
+	}
+}
+
 //DefineRunFlags defines the flags for the seed run command
 func DefineRunFlags() {
 	runCmd = flag.NewFlagSet(constants.RunCommand, flag.ContinueOnError)
@@ -573,6 +780,54 @@ func DefineRunFlags() {
 	runCmd.IntVar(&repeat, constants.ShortRepeatFlag, 1,
 		"Run the docker image the specified number of times")
 
+	var timeout int
+	runCmd.IntVar(&timeout, constants.TimeoutFlag, 0,
+		"Number of seconds the job may run before it is stopped (default is job.timeout from the seed manifest)")
+
+	var strict bool
+	runCmd.BoolVar(&strict, constants.StrictFlag, false,
+		"Fail the run if its output does not validate against the seed manifest")
+
+	var strictMediaTypes bool
+	runCmd.BoolVar(&strictMediaTypes, constants.StrictMediaTypesFlag, false,
+		"Fail instead of warning when input files don't match the media types declared in the seed manifest")
+
+	var format string
+	runCmd.StringVar(&format, constants.FormatFlag, constants.TextFormat,
+		"Format of the run report written to stdout: text, json or yaml")
+
+	var dryRun bool
+	runCmd.BoolVar(&dryRun, constants.DryRunFlag, false,
+		"Resolve and print the docker command without starting a container")
+
+	var emitScript string
+	runCmd.StringVar(&emitScript, constants.EmitScriptFlag, "",
+		"Shell script to write that reproduces the run without the seed CLI")
+
+	var shell bool
+	runCmd.BoolVar(&shell, constants.ShellFlag, false,
+		"Start an interactive shell in the job container instead of running the job command")
+
+	var keepOnFailure bool
+	runCmd.BoolVar(&keepOnFailure, constants.KeepOnFailureFlag, false,
+		"Keep the container of a failed run and print how to inspect it")
+
+	var force bool
+	runCmd.BoolVar(&force, constants.ForceRunFlag, false,
+		"Run the job even if the host doesn't have the cpus, memory or disk space it requires")
+
+	var runAs string
+	runCmd.StringVar(&runAs, constants.RunAsFlag, "",
+		"User to run the job container as: host, image, root or UID[:GID] (default is host)")
+
+	var params string
+	runCmd.StringVar(&params, constants.ParamsFlag, "",
+		"Job parameter file declaring the inputs, json inputs, settings and mounts of the run")
+
+	var containerRuntime string
+	runCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	// Run usage function
 	runCmd.Usage = func() {
 		PrintASCIIArt()
@@ -583,6 +838,13 @@ func DefineRunFlags() {
 //DefineListFlags defines the flags for the seed list command
 func DefineListFlags() {
 	listCmd = flag.NewFlagSet("list", flag.ExitOnError)
+	var format string
+	listCmd.StringVar(&format, constants.FormatFlag, constants.TextFormat,
+		"Format of the image list written to stdout: text, json or yaml")
+	var containerRuntime string
+	listCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	listCmd.Usage = func() {
 		PrintASCIIArt()
 		commands.PrintListUsage()
@@ -613,6 +875,10 @@ func DefineSearchFlags() {
 	searchCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
 	searchCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
 
+	var format string
+	searchCmd.StringVar(&format, constants.FormatFlag, constants.TextFormat,
+		"Format of the results written to stdout: text, json or yaml")
+
 	searchCmd.Usage = func() {
 		PrintASCIIArt()
 		commands.PrintSearchUsage()
@@ -678,6 +944,10 @@ func DefinePublishFlags() {
 	publishCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
 	publishCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
 
+	var containerRuntime string
+	publishCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	publishCmd.Usage = func() {
 		PrintASCIIArt()
 		commands.PrintPublishUsage()
@@ -749,6 +1019,10 @@ func DefinePullFlags() {
 	pullCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
 	pullCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
 
+	var containerRuntime string
+	pullCmd.StringVar(&containerRuntime, constants.RuntimeFlag, "",
+		"Container runtime to use: docker or podman (default is $SEED_RUNTIME or docker)")
+
 	pullCmd.Usage = func() {
 		PrintASCIIArt()
 		commands.PrintPullUsage()
@@ -779,6 +1053,10 @@ func DefineValidateFlags() {
 	validateCmd.StringVar(&version, constants.ShortVersionFlag, "1.0.0",
 		"Version of example seed manifest to use (default is 1.0.0).")
 
+	var format string
+	validateCmd.StringVar(&format, constants.FormatFlag, constants.TextFormat,
+		"Format of the validation result written to stdout: text, json or yaml")
+
 	validateCmd.Usage = func() {
 		PrintASCIIArt()
 		commands.PrintValidateUsage()
@@ -790,6 +1068,7 @@ func DefineFlags() {
 	// Seed subcommand flags
 	DefineBatchFlags()
 	DefineBuildFlags()
+	DefineCollectFlags()
 	DefineInitFlags()
 	DefineRunFlags()
 	DefineListFlags()
@@ -824,6 +1103,10 @@ func DefineFlags() {
 		cmd = batchCmd
 		minArgs = 2
 
+	case constants.CollectCommand:
+		cmd = collectCmd
+		minArgs = 3
+
 	case constants.BuildCommand:
 		cmd = buildCmd
 
@@ -890,7 +1173,50 @@ func DefineFlags() {
 			cmd.Usage()
 			panic(util.Exit{0})
 		}
+
+		// select the container runtime for commands that use one
+		if f := cmd.Lookup(constants.RuntimeFlag); f != nil {
+			if err := cliutil.SetRuntime(f.Value.String()); err != nil {
+				util.PrintUtil("%s", err.Error())
+				panic(util.Exit{1})
+			}
+		}
+	}
+}
+
+//formatFlag returns the value of the format flag of the given command, exiting if it
+// is not a supported output format
+func formatFlag(cmd *flag.FlagSet) string {
+	format := cmd.Lookup(constants.FormatFlag).Value.String()
+	if err := commands.CheckFormat(format); err != nil {
+		util.PrintUtil("%s", err.Error())
+		panic(util.Exit{1})
+	}
+	return format
+}
+
+//arrayFlag returns the values given for a repeatable flag of the given command. The values
+// are kept as given, so they may contain commas
+func arrayFlag(cmd *flag.FlagSet, name string) []string {
+	if values, ok := cmd.Lookup(name).Value.(*objects.ArrayFlags); ok {
+		return []string(*values)
+	}
+	return strings.Split(cmd.Lookup(name).Value.String(), ",")
+}
+
+//emitScript creates the script named by the emit-script flag of the given command, exiting
+// if it can't be created. nil is returned if the flag isn't set
+func emitScript(cmd *flag.FlagSet) io.WriteCloser {
+	fileName := cmd.Lookup(constants.EmitScriptFlag).Value.String()
+	if fileName == "" {
+		return nil
+	}
+	script, err := commands.CreateRunScript(fileName)
+	if err != nil {
+		util.PrintUtil("%s", err.Error())
+		panic(util.Exit{1})
 	}
+	return script
 }
 
 //PrintUsage prints the seed usage arguments
@@ -901,6 +1227,7 @@ func PrintUsage() {
 	util.PrintUtil("Commands:\n")
 	util.PrintUtil("  build \tBuilds Seed compliant Docker image\n")
 	util.PrintUtil("  batch \tExecutes Seed compliant docker image over multiple iterations\n")
+	util.PrintUtil("  collect\tCollects the json outputs of the rows of a batch into one table\n")
 	util.PrintUtil("  init  \tInitialize new project with example seed.manifest.json file\n")
 	util.PrintUtil("  list  \tLists all Seed compliant images residing on the local system\n")
 	util.PrintUtil("  publish\tPublishes Seed compliant images to remote Docker registry\n")
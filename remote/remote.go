@@ -0,0 +1,314 @@
+//Package remote fetches job inputs from and uploads job outputs to http(s), file and s3
+// URIs. Remote inputs are staged into a local cache directory so they can be mounted into
+// the job container like any other input
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//CacheDirEnvVar names the environment variable overriding the directory remote inputs are staged in
+const CacheDirEnvVar = "SEED_CACHE_DIR"
+
+var httpClient = &http.Client{}
+
+//Context aborts the downloads and uploads in progress once it is done
+var Context = context.Background()
+
+//IsURI returns true if s is a URI seed can fetch from or upload to: an http, https, file or s3 URI
+func IsURI(s string) bool {
+	for _, scheme := range []string{"http://", "https://", "file://", "s3://"} {
+		if len(s) > len(scheme) && strings.EqualFold(s[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+//Join appends path elements to a URI, or to a local path if s isn't a URI
+func Join(s string, elem ...string) string {
+	if !IsURI(s) {
+		return filepath.Join(append([]string{s}, elem...)...)
+	}
+	joined := strings.TrimSuffix(s, "/")
+	for _, e := range elem {
+		joined += "/" + strings.Trim(filepath.ToSlash(e), "/")
+	}
+	return joined
+}
+
+//DefaultCacheDir returns the directory remote inputs are staged in: $SEED_CACHE_DIR, or a seed
+// directory in the user cache directory
+func DefaultCacheDir() string {
+	if dir := os.Getenv(CacheDirEnvVar); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "seed")
+}
+
+//Stager downloads remote inputs into a cache directory. Each URI is downloaded once by a
+// Stager; later requests for the same URI return the local copy. Different URIs are
+// downloaded concurrently, and requests for a URI that is being downloaded wait for it
+type Stager struct {
+	Dir    string
+	lock   sync.Mutex
+	staged map[string]*stagedInput
+}
+
+// stagedInput is a download of a Stager. done is closed once local or err is set
+type stagedInput struct {
+	done  chan struct{}
+	local string
+	err   error
+}
+
+//NewStager returns a Stager downloading into dir, which is created when first needed
+func NewStager(dir string) *Stager {
+	return &Stager{Dir: dir, staged: map[string]*stagedInput{}}
+}
+
+//Stage returns the local path of the file or directory named by uri, downloading it into the
+// cache directory if needed. file URIs are used in place. An s3 URI ending with / is a prefix
+// and all the objects under it are downloaded into a directory. A failed download is
+// attempted again by the next request for the URI
+func (s *Stager) Stage(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("ERROR: Invalid URI %s: %s\n", uri, err.Error())
+	}
+	if strings.EqualFold(u.Scheme, "file") {
+		return filePath(u), nil
+	}
+
+	s.lock.Lock()
+	staged, ok := s.staged[uri]
+	if !ok {
+		staged = &stagedInput{done: make(chan struct{})}
+		s.staged[uri] = staged
+	}
+	s.lock.Unlock()
+	if ok {
+		<-staged.done
+		return staged.local, staged.err
+	}
+
+	staged.local, staged.err = s.download(u, uri)
+	if staged.err != nil {
+		s.lock.Lock()
+		delete(s.staged, uri)
+		s.lock.Unlock()
+	}
+	close(staged.done)
+	return staged.local, staged.err
+}
+
+// download fetches uri into its own directory in the cache directory
+func (s *Stager) download(u *url.URL, uri string) (string, error) {
+	sum := sha256.Sum256([]byte(uri))
+	dir := filepath.Join(s.Dir, "inputs", hex.EncodeToString(sum[:8]))
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = u.Host
+	}
+	local := filepath.Join(dir, name)
+	err := os.RemoveAll(dir)
+	if err == nil {
+		err = os.MkdirAll(dir, os.ModePerm)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ERROR: Unable to create cache directory %s: %s\n", dir, err.Error())
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		err = downloadFile(local, func(w io.Writer) error { return httpGet(uri, w) })
+	case "s3":
+		err = stageS3(NewS3ClientFromEnv(), u.Host, strings.TrimPrefix(u.Path, "/"), local)
+	default:
+		err = fmt.Errorf("unsupported scheme %s", u.Scheme)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("ERROR: Unable to download %s: %s\n", uri, err.Error())
+	}
+	return local, nil
+}
+
+// stageS3 downloads an object, or all the objects under a prefix ending with /, to local
+func stageS3(client *S3Client, bucket, key, local string) error {
+	if key != "" && !strings.HasSuffix(key, "/") {
+		return downloadFile(local, func(w io.Writer) error { return client.GetObject(bucket, key, w) })
+	}
+
+	keys, err := client.ListObjects(bucket, key)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no objects found under s3://%s/%s", bucket, key)
+	}
+	for _, k := range keys {
+		if strings.HasSuffix(k, "/") {
+			continue
+		}
+		file := filepath.Join(local, filepath.FromSlash(strings.TrimPrefix(k, key)))
+		if err = os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+			return err
+		}
+		object := k
+		if err = downloadFile(file, func(w io.Writer) error { return client.GetObject(bucket, object, w) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadFile writes the content written by get to file. A partial download is removed
+func downloadFile(file string, get func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(file), ".download-")
+	if err != nil {
+		return err
+	}
+	err = get(f)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+//Upload copies every file under dir to the same relative path under uri. Returns the URIs
+// of the uploaded files
+func Upload(dir, uri string) ([]string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: Invalid URI %s: %s\n", uri, err.Error())
+	}
+
+	var client *S3Client
+	if strings.EqualFold(u.Scheme, "s3") {
+		client = NewS3ClientFromEnv()
+	}
+
+	var uploaded []string
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if err = Context.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		target := Join(uri, rel)
+
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https":
+			err = httpPut(target, file)
+		case "file":
+			err = copyFile(file, filepath.Join(filePath(u), rel))
+		case "s3":
+			err = client.PutObject(u.Host, strings.TrimPrefix(path.Join(u.Path, filepath.ToSlash(rel)), "/"), file)
+		default:
+			err = fmt.Errorf("unsupported scheme %s", u.Scheme)
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: Unable to upload %s to %s: %s\n", file, target, err.Error())
+		}
+		uploaded = append(uploaded, target)
+		return nil
+	})
+	return uploaded, err
+}
+
+// filePath returns the local path of a file URI. The drive of a windows path such as
+// file:///C:/data follows the leading slash
+func filePath(u *url.URL) string {
+	p := u.Path
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+func httpGet(uri string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req.WithContext(Context))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", uri, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func httpPut(uri, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, uri, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	resp, err := httpClient.Do(req.WithContext(Context))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", uri, resp.Status)
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return downloadFile(dest, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
@@ -0,0 +1,269 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the hex encoded SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+//S3Client reads and writes objects in Amazon S3 or an S3 compatible object store such as
+// MinIO. Requests are signed with AWS Signature Version 4 if credentials are set, and sent
+// anonymously otherwise
+type S3Client struct {
+	// Endpoint is the URL of an S3 compatible store. Buckets are addressed in the path of the
+	// endpoint. If empty, the virtual-hosted endpoint of the bucket in Amazon S3 is used
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	http            *http.Client
+	now             func() time.Time
+}
+
+//NewS3ClientFromEnv returns a client configured by the standard AWS environment variables:
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, AWS_REGION or AWS_DEFAULT_REGION (default is
+// us-east-1), AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+func NewS3ClientFromEnv() *S3Client {
+	c := &S3Client{
+		Endpoint:        firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		Region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		http:            httpClient,
+		now:             time.Now,
+	}
+	if c.Region == "" {
+		c.Region = "us-east-1"
+	}
+	return c
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+//GetObject writes the content of an object to w
+func (c *S3Client) GetObject(bucket, key string, w io.Writer) error {
+	resp, err := c.do(http.MethodGet, bucket, key, nil, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+//PutObject uploads a file as an object
+func (c *S3Client) PutObject(bucket, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodPut, bucket, key, nil, f, size, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// listBucketResult is the response of a ListObjectsV2 request
+type listBucketResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+//ListObjects returns the keys of all the objects whose key starts with prefix
+func (c *S3Client) ListObjects(bucket, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := c.do(http.MethodGet, bucket, "", query, nil, 0, emptyPayloadHash)
+		if err != nil {
+			return keys, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return keys, fmt.Errorf("invalid list response: %s", err.Error())
+		}
+
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// s3Error is the body of an error response
+type s3Error struct {
+	Code    string
+	Message string
+}
+
+// do sends a request and returns the response if it succeeded. The body of an error
+// response is read and returned as an error
+func (c *S3Client) do(method, bucket, key string, query url.Values, body io.Reader, length int64, payloadHash string) (*http.Response, error) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, c.Region)
+	} else if key == "" {
+		key = bucket
+	} else {
+		key = bucket + "/" + key
+	}
+	rawURL := strings.TrimSuffix(endpoint, "/") + "/" + s3Escape(key, false)
+	if len(query) > 0 {
+		rawURL += "?" + canonicalQuery(query)
+	}
+
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+	c.sign(req, payloadHash)
+
+	resp, err := c.http.Do(req.WithContext(Context))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	var s3Err s3Error
+	if xml.Unmarshal(data, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("%s %s", s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("%s returned %s", endpoint, resp.Status)
+}
+
+// sign adds the AWS Signature Version 4 headers to a request. Requests are left unsigned
+// if no credentials are set
+func (c *S3Client) sign(req *http.Request, payloadHash string) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return
+	}
+
+	amzDate := c.now().UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders bytes.Buffer
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	for _, part := range []string{c.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery returns the query string with the parameters sorted and escaped as
+// Signature Version 4 requires
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes every byte of s except the unreserved characters of RFC 3986, and
+// slashes unless escapeSlash is true
+func s3Escape(s string, escapeSlash bool) string {
+	var buffer bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' && !escapeSlash {
+			buffer.WriteByte(b)
+		} else {
+			fmt.Fprintf(&buffer, "%%%02X", b)
+		}
+	}
+	return buffer.String()
+}
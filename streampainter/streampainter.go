@@ -1,24 +1,45 @@
 package streampainter
 
 import (
+	"io"
 	"os"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 type StreamPainter struct {
 	paintColor color.Attribute
+	painter    *color.Color
+	out        io.Writer
 }
 
 /*
- * NewStreamPainter returns new streampainter
+ * NewStreamPainter returns new streampainter writing to stderr. Text is only colored
+ * when stderr is a terminal
  */
 func NewStreamPainter(textColor color.Attribute) *StreamPainter {
-	return &StreamPainter{textColor}
+	painter := color.New(textColor)
+	if IsTerminal(os.Stderr) {
+		painter.EnableColor()
+	} else {
+		painter.DisableColor()
+	}
+	return &StreamPainter{textColor, painter, os.Stderr}
 }
 
 func (w *StreamPainter) Write(p []byte) (int, error) {
 	n := len(p)
-	color.New(w.paintColor).Fprintf(os.Stderr, string(p[:n]))
+	w.painter.Fprint(w.out, string(p[:n]))
 	return n, nil
 }
+
+/*
+ * IsTerminal returns true if the file is a terminal that can display colors
+ */
+func IsTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}